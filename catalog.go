@@ -0,0 +1,187 @@
+package metricer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"go.melnyk.org/mlog"
+)
+
+// Formats of metrics catalog
+const (
+	CatalogFormatJSON     = "json"
+	CatalogFormatMarkdown = "markdown"
+)
+
+// CatalogEntry describes registered metric or health check
+type CatalogEntry struct {
+	Name            string   `json:"name"`
+	Type            string   `json:"type"`
+	Help            string   `json:"help"`
+	Unit            string   `json:"unit,omitempty"`
+	Stability       string   `json:"stability,omitempty"`
+	DeprecatedSince string   `json:"deprecated_since,omitempty"`
+	Replacement     string   `json:"replacement,omitempty"`
+	Labels          []string `json:"labels"`
+	Groups          []string `json:"groups,omitempty"`
+	DependsOn       []string `json:"depends_on,omitempty"`
+	Source          string   `json:"source,omitempty"` // registration call site, e.g. pkg/file.go:42
+}
+
+// callSite returns location of the code calling registration function
+func callSite() string {
+	_, file, line, ok := runtime.Caller(2)
+	if !ok {
+		return ""
+	}
+	return filepath.Base(filepath.Dir(file)) + "/" + filepath.Base(file) + ":" + strconv.Itoa(line)
+}
+
+// Catalog lists all registered metrics and health checks in order of registration
+func (h *host) Catalog() []CatalogEntry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var hostlabels []string
+	for _, v := range h.metrics {
+		if v, ok := v.(Label); ok {
+			hostlabels = append(hostlabels, v.Name())
+		}
+	}
+	withHostLabels := func(labels ...string) []string {
+		return append(append(make([]string, 0, len(hostlabels)+len(labels)), hostlabels...), labels...)
+	}
+
+	catalog := make([]CatalogEntry, 0, len(h.metrics)+len(h.healthchecks))
+	for _, v := range h.metrics {
+		meta := metadataOf(v)
+		entry := CatalogEntry{
+			Unit:            meta.unit,
+			Stability:       string(meta.stability),
+			DeprecatedSince: meta.deprecatedSince,
+			Replacement:     meta.replacement,
+			Source:          h.sources[v],
+		}
+
+		switch v := v.(type) {
+		case Label:
+			entry.Name, entry.Help, entry.Type = v.Name(), v.Help(), "label"
+			entry.Labels = []string{}
+		case Counter:
+			entry.Name, entry.Help, entry.Type = v.Name(), v.Help(), kindCounter.String()
+			entry.Labels = withHostLabels()
+		case Gauge:
+			entry.Name, entry.Help, entry.Type = v.Name(), v.Help(), kindGauge.String()
+			entry.Labels = withHostLabels()
+		case Histogram:
+			entry.Name, entry.Help, entry.Type = v.Name(), v.Help(), kindHistogram.String()
+			entry.Labels = withHostLabels("le")
+		case Meter:
+			entry.Name, entry.Help, entry.Type = v.Name(), v.Help(), "meter"
+			entry.Labels = withHostLabels("window")
+		case familyCollector:
+			for _, f := range v.families() {
+				labels := withHostLabels()
+				if len(f.series) > 0 {
+					for _, l := range f.series[0].labels {
+						labels = append(labels, l.name)
+					}
+				}
+				catalog = append(catalog, CatalogEntry{Name: f.name, Type: f.kind.String(), Help: f.help, Labels: labels, Source: entry.Source})
+			}
+			continue
+		default:
+			continue
+		}
+
+		catalog = append(catalog, entry)
+	}
+
+	for _, v := range h.healthchecks {
+		catalog = append(catalog, CatalogEntry{
+			Name:      v.Name(),
+			Type:      "health",
+			Help:      v.Help(),
+			Labels:    withHostLabels("check"),
+			Groups:    v.groups,
+			DependsOn: v.dependencies,
+			Source:    h.sources[v],
+		})
+	}
+
+	return catalog
+}
+
+var markdownEscaper = strings.NewReplacer("|", `\|`, "\n", " ")
+
+// WriteCatalog writes catalog as JSON or Markdown table, e.g. for documentation
+func WriteCatalog(w io.Writer, catalog []CatalogEntry, format string) error {
+	switch format {
+	case CatalogFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(catalog)
+	case CatalogFormatMarkdown:
+	default:
+		return errCatalogFormatUnknown
+	}
+
+	if _, err := io.WriteString(w, "| Name | Type | Unit | Labels | Help | Source |\n|---|---|---|---|---|---|\n"); err != nil {
+		return err
+	}
+	for _, v := range catalog {
+		help := v.Help
+		if v.DeprecatedSince != "" {
+			notice := "**Deprecated** since " + v.DeprecatedSince
+			if v.Replacement != "" {
+				notice += ", use `" + v.Replacement + "`"
+			}
+			help = notice + ". " + help
+		}
+
+		labels := ""
+		if len(v.Labels) > 0 {
+			labels = "`" + strings.Join(v.Labels, "`, `") + "`"
+		}
+
+		_, err := fmt.Fprintf(w, "| `%s` | %s | %s | %s | %s | %s |\n", v.Name, v.Type, v.Unit, labels,
+			markdownEscaper.Replace(help), v.Source)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *host) metricsCatalog(w http.ResponseWriter, r *http.Request) {
+	if !h.enterHandler(w, r) {
+		return
+	}
+	defer h.wg.Done()
+
+	// only GET method is allowed
+	if r.Method != http.MethodGet {
+		h.handlerError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	h.log.Event(mlog.Verbose, func(e mlog.Event) {
+		e.String("msg", "Get metrics catalog")
+		e.String("remote", r.RemoteAddr)
+	})
+
+	if r.URL.Query().Get("format") == CatalogFormatMarkdown || strings.Contains(r.Header.Get("Accept"), acceptMarkdown) {
+		w.Header().Set("Content-Type", contenttypeMarkdown)
+		WriteCatalog(w, h.Catalog(), CatalogFormatMarkdown)
+		return
+	}
+
+	w.Header().Set("Content-Type", contenttypeJSON)
+	WriteCatalog(w, h.Catalog(), CatalogFormatJSON)
+}
@@ -0,0 +1,128 @@
+package metricer
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.melnyk.org/mlog/testlog"
+)
+
+func TestCatalog(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewCounter("requests_total", "requests help", StabilityLevel(StabilityStable))
+	mhost.registerHistogram("latency_seconds", "latency help", nil, Unit("seconds"), Deprecated("1.2", "duration_seconds"))
+	mhost.NewHealthCheck("db", "db help", func() error { return nil }, HealthGroup("ready"))
+
+	entries := make(map[string]CatalogEntry)
+	for _, v := range mhost.Catalog() {
+		entries[v.Name] = v
+	}
+
+	counter := entries["requests_total"]
+	if counter.Type != "counter" || counter.Help != "requests help" || counter.Stability != "stable" {
+		t.Errorf("Expected: counter entry, but got %v", counter)
+	}
+	if len(counter.Labels) != 1 || counter.Labels[0] != rtmetricos {
+		t.Errorf("Expected: host labels, but got %v", counter.Labels)
+	}
+	if !strings.Contains(counter.Source, "catalog_test.go:") {
+		t.Errorf("Expected: call site in test file, but got %s", counter.Source)
+	}
+
+	histogram := entries["latency_seconds"]
+	if histogram.Type != "histogram" || histogram.Unit != "seconds" || histogram.DeprecatedSince != "1.2" || histogram.Replacement != "duration_seconds" {
+		t.Errorf("Expected: histogram entry, but got %v", histogram)
+	}
+	if labels := strings.Join(histogram.Labels, ","); labels != rtmetricos+",le" {
+		t.Errorf("Expected: histogram labels, but got %s", labels)
+	}
+
+	health := entries["db"]
+	if health.Type != "health" || len(health.Groups) != 1 || health.Groups[0] != "ready" || !strings.Contains(health.Source, "catalog_test.go:") {
+		t.Errorf("Expected: health check entry, but got %v", health)
+	}
+
+	// internal metrics are registered by the host
+	if label := entries[rtmetricos]; label.Type != "label" || !strings.Contains(label.Source, "metricer.go:") {
+		t.Errorf("Expected: internal label entry, but got %v", label)
+	}
+}
+
+func TestCatalogHealthCheckRemoved(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	check, _ := mhost.NewHealthCheck("db", "db help", func() error { return nil })
+	check.Remove()
+
+	for _, v := range mhost.Catalog() {
+		if v.Name == "db" {
+			t.Error("Expected: removed health check is not listed")
+		}
+	}
+}
+
+func TestWriteCatalog(t *testing.T) {
+	catalog := []CatalogEntry{
+		{Name: "latency_seconds", Type: "histogram", Help: "latency | help", Unit: "seconds", Labels: []string{"_os", "le"},
+			DeprecatedSince: "1.2", Replacement: "duration_seconds", Source: "app/main.go:10"},
+	}
+
+	var b bytes.Buffer
+	if err := WriteCatalog(&b, catalog, CatalogFormatMarkdown); err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+	expected := "| Name | Type | Unit | Labels | Help | Source |\n|---|---|---|---|---|---|\n" +
+		"| `latency_seconds` | histogram | seconds | `_os`, `le` | **Deprecated** since 1.2, use `duration_seconds`. latency \\| help | app/main.go:10 |\n"
+	if b.String() != expected {
+		t.Errorf("Expected: %s, but got %s", expected, b.String())
+	}
+
+	b.Reset()
+	if err := WriteCatalog(&b, catalog, CatalogFormatJSON); err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+	var decoded []CatalogEntry
+	if err := json.Unmarshal(b.Bytes(), &decoded); err != nil || len(decoded) != 1 || decoded[0].Source != "app/main.go:10" {
+		t.Errorf("Expected: catalog in JSON, but got %v %s", err, b.String())
+	}
+
+	if err := WriteCatalog(&b, catalog, "xml"); err != errCatalogFormatUnknown {
+		t.Errorf("Expected: %v, but got %v", errCatalogFormatUnknown, err)
+	}
+}
+
+func TestServerMetricsCatalog(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewCounter("requests_total", "requests help")
+	muxer := mhost.buildMuxer()
+
+	tests := []struct {
+		url         string
+		accept      string
+		contenttype string
+		content     string
+	}{
+		{"http://test/metrics/catalog", "", contenttypeJSON, `"name": "requests_total"`},
+		{"http://test/metrics/catalog?format=markdown", "", contenttypeMarkdown, "| `requests_total` | counter |"},
+		{"http://test/metrics/catalog", "text/markdown", contenttypeMarkdown, "| `requests_total` | counter |"},
+	}
+
+	for i, v := range tests {
+		req := httptest.NewRequest("GET", v.url, nil)
+		if v.accept != "" {
+			req.Header.Set("Accept", v.accept)
+		}
+		w := httptest.NewRecorder()
+		muxer.ServeHTTP(w, req)
+
+		resp := w.Result()
+		if resp.Header.Get("Content-Type") != v.contenttype {
+			t.Errorf("Expected (%d): %s, but got %s", i, v.contenttype, resp.Header.Get("Content-Type"))
+		}
+		if !strings.Contains(w.Body.String(), v.content) {
+			t.Errorf("Expected (%d): %s, but got %s", i, v.content, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package metricer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.melnyk.org/mlog"
+)
+
+// CheckpointConfig represents configuration of checkpoint file keeping values of persistent metrics
+type CheckpointConfig struct {
+	// Path of checkpoint file
+	Path string `json:"path,omitempty" yaml:"path"`
+
+	// Interval between checkpoints, the last one is written on stop
+	Interval time.Duration `json:"interval,omitempty" yaml:"interval"`
+}
+
+// Validate checks checkpoint config structure
+func (config *CheckpointConfig) Validate() error {
+	if config.Path == "" {
+		return errCheckpointPathEmpty
+	}
+	if config.Interval <= 0 {
+		config.Interval = defaultCheckpointInterval
+	}
+	return nil
+}
+
+// checkpointData represents content of checkpoint file, values are stored by metric name
+type checkpointData struct {
+	Version  int              `json:"version"`
+	Time     time.Time        `json:"time"`
+	Counters map[string]int64 `json:"counters"`
+	Gauges   map[string]int64 `json:"gauges"`
+}
+
+func newCheckpointData() *checkpointData {
+	return &checkpointData{
+		Version:  checkpointVersion,
+		Counters: make(map[string]int64),
+		Gauges:   make(map[string]int64),
+	}
+}
+
+// loadCheckpoint reads checkpoint file, missing file is not an error
+func loadCheckpoint(path string) (*checkpointData, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return newCheckpointData(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	restored := newCheckpointData()
+	if err := json.Unmarshal(data, restored); err != nil {
+		return nil, errCheckpointCorrupt
+	}
+	if restored.Version != checkpointVersion {
+		return nil, errCheckpointVersion
+	}
+
+	// missing sections are treated as empty ones
+	if restored.Counters == nil {
+		restored.Counters = make(map[string]int64)
+	}
+	if restored.Gauges == nil {
+		restored.Gauges = make(map[string]int64)
+	}
+
+	return restored, nil
+}
+
+// saveCheckpoint writes checkpoint into temporary file and renames it, so the file is always complete
+func saveCheckpoint(path string, checkpoint *checkpointData) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+type checkpointer struct {
+	config CheckpointConfig
+	values func() *checkpointData
+}
+
+func newCheckpointer(config CheckpointConfig, values func() *checkpointData) *checkpointer {
+	return &checkpointer{config: config, values: values}
+}
+
+func (exporter *checkpointer) name() string {
+	return "checkpoint"
+}
+
+func (exporter *checkpointer) interval() time.Duration {
+	return exporter.config.Interval
+}
+
+func (exporter *checkpointer) close() error {
+	return nil
+}
+
+func (exporter *checkpointer) push(snap *snapshot) error {
+	return saveCheckpoint(exporter.config.Path, exporter.values())
+}
+
+// restoreCheckpoint loads values of persistent metrics, corrupted or incompatible file is moved aside
+func (h *host) restoreCheckpoint() {
+	path := h.config.Checkpoint.Path
+	restored, err := loadCheckpoint(path)
+	if err == nil {
+		h.restored = restored
+		return
+	}
+
+	h.log.Event(mlog.Warning, func(e mlog.Event) {
+		e.String("msg", "Checkpoint cannot be restored")
+		e.String("path", path)
+		e.String("err", err.Error())
+	})
+
+	if err == errCheckpointCorrupt || err == errCheckpointVersion {
+		os.Rename(path, path+checkpointCorruptSuffix)
+	}
+}
+
+// checkpointValues returns current values of persistent metrics, restored values of metrics
+// which are not registered yet are kept
+func (h *host) checkpointValues() *checkpointData {
+	checkpoint := newCheckpointData()
+	checkpoint.Time = time.Now()
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for k, v := range h.restored.Counters {
+		checkpoint.Counters[k] = v
+	}
+	for k, v := range h.restored.Gauges {
+		checkpoint.Gauges[k] = v
+	}
+
+	for _, v := range h.persistent {
+		switch v := v.(type) {
+		case Counter:
+			checkpoint.Counters[v.Name()] = v.Count()
+		case Gauge:
+			checkpoint.Gauges[v.Name()] = v.Value()
+		}
+	}
+
+	return checkpoint
+}
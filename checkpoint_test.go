@@ -0,0 +1,145 @@
+package metricer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.melnyk.org/mlog/testlog"
+)
+
+func TestCheckpointConfig(t *testing.T) {
+	config := CheckpointConfig{}
+	if err := config.Validate(); err != errCheckpointPathEmpty {
+		t.Errorf("Expected: %v, but got %v", errCheckpointPathEmpty, err)
+	}
+
+	config = CheckpointConfig{Path: "checkpoint.json"}
+	if err := config.Validate(); err != nil || config.Interval != defaultCheckpointInterval {
+		t.Errorf("Expected: default interval, but got %v %s", err, config.Interval)
+	}
+}
+
+func TestCheckpointSaveLoad(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoint.json")
+
+	restored, err := loadCheckpoint(path)
+	if err != nil || len(restored.Counters) != 0 || len(restored.Gauges) != 0 {
+		t.Fatalf("Expected: empty checkpoint for missing file, but got %v %v", err, restored)
+	}
+
+	checkpoint := newCheckpointData()
+	checkpoint.Counters["orders"] = 42
+	checkpoint.Gauges["queue"] = -3
+	if err := saveCheckpoint(path, checkpoint); err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+
+	restored, err = loadCheckpoint(path)
+	if err != nil || restored.Counters["orders"] != 42 || restored.Gauges["queue"] != -3 {
+		t.Errorf("Expected: saved values, but got %v %v", err, restored)
+	}
+
+	// temporary files are not left
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 1 {
+		t.Errorf("Expected: only checkpoint file, but got %v", files)
+	}
+}
+
+func TestCheckpointLoadInvalid(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoint.json")
+
+	tests := []struct {
+		content string
+		err     error
+	}{
+		{`{"version":1,"counters":{"orders":`, errCheckpointCorrupt},
+		{`{"version":1,"counters":{"orders":"many"}}`, errCheckpointCorrupt},
+		{`{"version":2,"counters":{"orders":1}}`, errCheckpointVersion},
+		{`{"version":1}`, nil},
+	}
+
+	for i, v := range tests {
+		ioutil.WriteFile(path, []byte(v.content), 0644)
+		restored, err := loadCheckpoint(path)
+		if err != v.err {
+			t.Errorf("Expected (%d): %v, but got %v", i, v.err, err)
+		}
+		if err == nil && (restored.Counters == nil || restored.Gauges == nil) {
+			t.Errorf("Expected (%d): empty sections, but got %v", i, restored)
+		}
+	}
+}
+
+func TestCheckpointRestore(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoint.json")
+
+	checkpoint := newCheckpointData()
+	checkpoint.Counters["orders"] = 42
+	checkpoint.Counters["queue"] = 7
+	checkpoint.Counters["removed"] = 1
+	checkpoint.Gauges["balance"] = 100
+	saveCheckpoint(path, checkpoint)
+
+	mhost := NewHost(&Config{Checkpoint: &CheckpointConfig{Path: path}}, testlog.NewLogbook()).(*host)
+	orders := mhost.NewCounter("orders", "orders help", Persistent())
+	balance := mhost.NewGauge("balance", "balance help", Persistent())
+	queue := mhost.NewGauge("queue", "queue help", Persistent())
+	other := mhost.NewCounter("orders", "not persistent")
+
+	if orders.Count() != 42 || balance.Value() != 100 {
+		t.Errorf("Expected: restored values, but got %d and %d", orders.Count(), balance.Value())
+	}
+	if queue.Value() != 0 || other.Count() != 0 {
+		t.Errorf("Expected: values are not restored for other types and not persistent metrics, but got %d and %d", queue.Value(), other.Count())
+	}
+
+	orders.Inc(8)
+	queue.Update(5)
+	values := mhost.checkpointValues()
+	if values.Counters["orders"] != 50 || values.Gauges["queue"] != 5 || values.Counters["removed"] != 1 {
+		t.Errorf("Expected: current and not registered values, but got %v", values)
+	}
+}
+
+func TestCheckpointRestoreCorrupt(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoint.json")
+	ioutil.WriteFile(path, []byte("garbage"), 0644)
+
+	mhost := NewHost(&Config{Checkpoint: &CheckpointConfig{Path: path}}, testlog.NewLogbook())
+	if orders := mhost.NewCounter("orders", "orders help", Persistent()); orders.Count() != 0 {
+		t.Errorf("Expected: 0, but got %d", orders.Count())
+	}
+
+	if _, err := os.Stat(path + checkpointCorruptSuffix); err != nil {
+		t.Errorf("Expected: corrupted file is moved aside, but got %s", err.Error())
+	}
+}
+
+func TestCheckpointStartStop(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoint.json")
+	config := &Config{Port: 1000000, Checkpoint: &CheckpointConfig{Path: path, Interval: time.Hour}}
+
+	mhost := NewHost(config, testlog.NewLogbook())
+	mhost.NewCounter("orders", "orders help", Persistent()).Inc(3)
+	mhost.Start()
+	mhost.Stop()
+
+	// the checkpoint is written on stop and restored by the next instance
+	mhost = NewHost(config, testlog.NewLogbook())
+	if orders := mhost.NewCounter("orders", "orders help", Persistent()); orders.Count() != 3 {
+		t.Errorf("Expected: 3, but got %d", orders.Count())
+	}
+}
@@ -0,0 +1,93 @@
+package metricer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	bufferPool = sync.Pool{New: func() interface{} {
+		return new(bytes.Buffer)
+	}}
+	gzipPool = sync.Pool{New: func() interface{} {
+		return gzip.NewWriter(ioutil.Discard)
+	}}
+)
+
+// bufferedResponse keeps response body in memory to decide whether it should be compressed
+type bufferedResponse struct {
+	http.ResponseWriter
+	buf *bytes.Buffer
+}
+
+func (w *bufferedResponse) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+// acceptsGzip checks whether Accept-Encoding header allows gzip encoding,
+// explicit gzip entry takes precedence over the wildcard regardless of the order
+func acceptsGzip(header string) bool {
+	var listed, accepted, wildcard bool
+	for _, v := range strings.Split(header, ",") {
+		parts := strings.Split(v, ";")
+		coding := strings.TrimSpace(parts[0])
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+
+		allowed := true
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				value, err := strconv.ParseFloat(param[2:], 64)
+				allowed = err == nil && value > 0
+			}
+		}
+
+		if coding == "gzip" {
+			listed, accepted = true, allowed
+		} else {
+			wildcard = allowed
+		}
+	}
+
+	if listed {
+		return accepted
+	}
+	return wildcard
+}
+
+// compressed calls handler with buffered response writer, the body is compressed with gzip when
+// the client accepts it and size of the body reaches the threshold
+func (h *host) compressed(w http.ResponseWriter, r *http.Request, handler func(http.ResponseWriter, *http.Request)) {
+	threshold := h.config.CompressionThreshold
+	if threshold < 0 {
+		handler(w, r)
+		return
+	}
+
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufferPool.Put(buf)
+
+	handler(&bufferedResponse{ResponseWriter: w, buf: buf}, r)
+
+	w.Header().Add("Vary", "Accept-Encoding")
+	if buf.Len() < threshold || !acceptsGzip(strings.Join(r.Header["Accept-Encoding"], ",")) {
+		w.Write(buf.Bytes())
+		return
+	}
+
+	zw := gzipPool.Get().(*gzip.Writer)
+	zw.Reset(w)
+	defer gzipPool.Put(zw)
+
+	w.Header().Set("Content-Encoding", "gzip")
+	zw.Write(buf.Bytes())
+	zw.Close()
+}
@@ -0,0 +1,85 @@
+package metricer
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.melnyk.org/mlog/testlog"
+)
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header string
+		result bool
+	}{
+		{"gzip", true},
+		{"deflate, gzip;q=1.0, *;q=0.5", true},
+		{"br;q=1.0, gzip;q=0.8", true},
+		{"*", true},
+		{"gzip;q=0", false},
+		{"*;q=0.5, gzip;q=0", false},
+		{"gzip;q=0, *", false},
+		{"*;q=0, gzip", true},
+		{"identity", false},
+		{"", false},
+	}
+
+	for i, v := range tests {
+		if result := acceptsGzip(v.header); result != v.result {
+			t.Errorf("Expected (%d): %t, but got %t", i, v.result, result)
+		}
+	}
+}
+
+func TestServerMetricsValuesGzip(t *testing.T) {
+	tests := []struct {
+		threshold int
+		encoding  string
+		gzip      bool
+	}{
+		{1, "gzip", true},
+		{1, "", false},
+		{1, "gzip;q=0", false},
+		{1 << 20, "gzip", false},
+		{-1, "gzip", false},
+	}
+
+	for i, v := range tests {
+		mhost := NewHost(&Config{CompressionThreshold: v.threshold}, testlog.NewLogbook()).(*host)
+		mhost.NewCounter("counter", "counter help").Inc(3)
+
+		req := httptest.NewRequest("GET", "http://test/metrics/values", nil)
+		if v.encoding != "" {
+			req.Header.Set("Accept-Encoding", v.encoding)
+		}
+		w := httptest.NewRecorder()
+		mhost.metricsValues(w, req)
+
+		resp := w.Result()
+		if resp.Header.Get("Content-Type") != contenttypeText {
+			t.Errorf("Expected (%d): %s, but got %s", i, contenttypeText, resp.Header.Get("Content-Type"))
+		}
+
+		body := resp.Body
+		if v.gzip {
+			if resp.Header.Get("Content-Encoding") != "gzip" {
+				t.Fatalf("Expected (%d): gzip encoding, but got %s", i, resp.Header.Get("Content-Encoding"))
+			}
+			zr, err := gzip.NewReader(resp.Body)
+			if err != nil {
+				t.Fatalf("Expected (%d): gzip body, but got %s", i, err.Error())
+			}
+			body = zr
+		} else if resp.Header.Get("Content-Encoding") != "" {
+			t.Errorf("Expected (%d): no encoding, but got %s", i, resp.Header.Get("Content-Encoding"))
+		}
+
+		data, _ := ioutil.ReadAll(body)
+		if !strings.Contains(string(data), "# TYPE counter counter\n") {
+			t.Errorf("Expected (%d): metrics in text format, but got %s", i, string(data))
+		}
+	}
+}
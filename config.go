@@ -1,5 +1,10 @@
 package metricer
 
+import (
+	"fmt"
+	"time"
+)
+
 // Config represents configuration structure
 type Config struct {
 	// AllowExternal
@@ -10,6 +15,51 @@ type Config struct {
 
 	// Port
 	Port uint `jsonL:"port, omit" yaml:"port"`
+
+	// DebugToken is bearer token required by debug endpoints changing state of the host
+	DebugToken string `json:"debug_token,omitempty" yaml:"debug_token"`
+
+	// DrainOnStop enables drain mode on stop and waits for DrainGrace before server shutdown
+	DrainOnStop bool `json:"drain_on_stop,omitempty" yaml:"drain_on_stop"`
+
+	// DrainGrace
+	DrainGrace time.Duration `json:"drain_grace,omitempty" yaml:"drain_grace"`
+
+	// HideDeprecated excludes deprecated metrics from all outputs
+	HideDeprecated bool `json:"hide_deprecated,omitempty" yaml:"hide_deprecated"`
+
+	// CompressionThreshold is min size of metrics response in bytes compressed with gzip, negative value disables compression
+	CompressionThreshold int `json:"compression_threshold,omitempty" yaml:"compression_threshold"`
+
+	// StreamInterval between events of metrics stream
+	StreamInterval time.Duration `json:"stream_interval,omitempty" yaml:"stream_interval"`
+
+	// MaxStreams is max number of concurrent metrics streams
+	MaxStreams int `json:"max_streams,omitempty" yaml:"max_streams"`
+
+	// History enables in-memory history of metrics values
+	History *HistoryConfig `json:"history,omitempty" yaml:"history"`
+
+	// Checkpoint enables saving values of persistent metrics into file
+	Checkpoint *CheckpointConfig `json:"checkpoint,omitempty" yaml:"checkpoint"`
+
+	// Statsd enables pushing metrics to StatsD daemon
+	Statsd *StatsdConfig `json:"statsd,omitempty" yaml:"statsd"`
+
+	// Graphite enables pushing metrics to Carbon endpoint
+	Graphite *GraphiteConfig `json:"graphite,omitempty" yaml:"graphite"`
+
+	// Influx enables pushing metrics to InfluxDB write endpoint
+	Influx *InfluxConfig `json:"influx,omitempty" yaml:"influx"`
+
+	// Otlp enables pushing metrics to OpenTelemetry collector over OTLP/HTTP
+	Otlp *OtlpConfig `json:"otlp,omitempty" yaml:"otlp"`
+
+	// File enables writing metrics snapshots to local files
+	File *FileConfig `json:"file,omitempty" yaml:"file"`
+
+	// RemoteWrite enables pushing metrics to Prometheus remote write endpoint
+	RemoteWrite *RemoteWriteConfig `json:"remote_write,omitempty" yaml:"remote_write"`
 }
 
 // Validate checks config structure
@@ -21,5 +71,87 @@ func (config *Config) Validate() error {
 	if config.Port == 0 {
 		config.Port = defaultPort
 	}
-	return nil
+
+	if config.DrainOnStop && config.DrainGrace == 0 {
+		config.DrainGrace = defaultDrainGrace
+	}
+
+	if config.CompressionThreshold == 0 {
+		config.CompressionThreshold = defaultCompressionThreshold
+	}
+
+	if config.StreamInterval <= 0 {
+		config.StreamInterval = defaultStreamInterval
+	}
+
+	if config.MaxStreams <= 0 {
+		config.MaxStreams = defaultMaxStreams
+	}
+
+	// sections failed validation are disabled, the rest of config stays in use
+	var err error
+	if config.History != nil {
+		if e := config.History.Validate(); e != nil {
+			config.History = nil
+			err = sectionError(err, "history", e)
+		}
+	}
+
+	if config.Checkpoint != nil {
+		if e := config.Checkpoint.Validate(); e != nil {
+			config.Checkpoint = nil
+			err = sectionError(err, "checkpoint", e)
+		}
+	}
+
+	if config.Statsd != nil {
+		if e := config.Statsd.Validate(); e != nil {
+			config.Statsd = nil
+			err = sectionError(err, "statsd", e)
+		}
+	}
+
+	if config.Graphite != nil {
+		if e := config.Graphite.Validate(); e != nil {
+			config.Graphite = nil
+			err = sectionError(err, "graphite", e)
+		}
+	}
+
+	if config.Influx != nil {
+		if e := config.Influx.Validate(); e != nil {
+			config.Influx = nil
+			err = sectionError(err, "influx", e)
+		}
+	}
+
+	if config.Otlp != nil {
+		if e := config.Otlp.Validate(); e != nil {
+			config.Otlp = nil
+			err = sectionError(err, "otlp", e)
+		}
+	}
+
+	if config.File != nil {
+		if e := config.File.Validate(); e != nil {
+			config.File = nil
+			err = sectionError(err, "file", e)
+		}
+	}
+
+	if config.RemoteWrite != nil {
+		if e := config.RemoteWrite.Validate(); e != nil {
+			config.RemoteWrite = nil
+			err = sectionError(err, "remote_write", e)
+		}
+	}
+	return err
+}
+
+// sectionError adds error of disabled config section to errors of previous sections
+func sectionError(err error, section string, e error) error {
+	if err != nil {
+		return fmt.Errorf("%w; config section %s is disabled: %v", err, section, e)
+	}
+	return fmt.Errorf("Config section %s is disabled: %w", section, e)
 }
@@ -1,6 +1,11 @@
 package metricer
 
-import "testing"
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestConfigBasic(t *testing.T) {
 	cfg := &Config{}
@@ -19,3 +24,58 @@ func TestConfigNil(t *testing.T) {
 		t.Error("Expected: error, but got nil")
 	}
 }
+
+func TestConfigDrainGrace(t *testing.T) {
+	cfg := &Config{DrainOnStop: true}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected: no errors, but got %s", err.Error())
+	}
+
+	if cfg.DrainGrace != defaultDrainGrace {
+		t.Errorf("Expected: default drain grace value, but got %s", cfg.DrainGrace)
+	}
+
+	cfg = &Config{DrainOnStop: true, DrainGrace: time.Second}
+	cfg.Validate()
+	if cfg.DrainGrace != time.Second {
+		t.Errorf("Expected: configured drain grace value, but got %s", cfg.DrainGrace)
+	}
+}
+
+func TestConfigCompressionThreshold(t *testing.T) {
+	cfg := &Config{}
+	cfg.Validate()
+	if cfg.CompressionThreshold != defaultCompressionThreshold {
+		t.Errorf("Expected: default compression threshold, but got %d", cfg.CompressionThreshold)
+	}
+}
+
+func TestConfigStream(t *testing.T) {
+	cfg := &Config{}
+	cfg.Validate()
+	if cfg.StreamInterval != defaultStreamInterval || cfg.MaxStreams != defaultMaxStreams {
+		t.Errorf("Expected: default stream settings, but got %s and %d", cfg.StreamInterval, cfg.MaxStreams)
+	}
+}
+
+func TestConfigInvalidSection(t *testing.T) {
+	cfg := &Config{
+		Port:        1234,
+		EnableDebug: true,
+		File:        &FileConfig{Path: "metrics.log", Format: "xml"},
+		Checkpoint:  &CheckpointConfig{},
+		Statsd:      &StatsdConfig{},
+	}
+
+	err := cfg.Validate()
+	if !errors.Is(err, errCheckpointPathEmpty) || !strings.Contains(err.Error(), errFileFormatUnknown.Error()) {
+		t.Errorf("Expected: errors of invalid sections, but got %v", err)
+	}
+
+	if cfg.File != nil || cfg.Checkpoint != nil {
+		t.Errorf("Expected: invalid sections disabled, but got %v and %v", cfg.File, cfg.Checkpoint)
+	}
+	if cfg.Statsd == nil || cfg.Port != 1234 || !cfg.EnableDebug {
+		t.Errorf("Expected: valid settings kept, but got %v", cfg)
+	}
+}
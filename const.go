@@ -1,35 +1,114 @@
 package metricer
 
+import "time"
+
 const (
 	logname = "metric"
 )
 
 const (
-	rtmetricgoroutines     = "_goroutines"
-	rtmetricgoroutineshelp = "Number of goroutines running by app [internal]"
-	rtmetricos             = "_os"
-	rtmetricoshelp         = "Application platform [internal]"
-	rtmetricnumcpu         = "_num_cpu"
-	rtmetricnumcpuhelp     = "Number of CPU [internal]"
-	rtmetricmemalloc       = "_mem_alloc"
-	rtmetricmemallochelp   = "Number of allocated memory for whole app in bytes [internal]"
-	healthcheckfailed      = "_failed_healthchecks"
-	healthcheckfailedhelp  = "Number of failed health checks [internal]"
-	rtuptime               = "uptime"
-	rtuptimehelp           = "Application uptime in nanosec [internal]"
+	rtmetricgoroutines         = "_goroutines"
+	rtmetricgoroutineshelp     = "Number of goroutines running by app [internal]"
+	rtmetricos                 = "_os"
+	rtmetricoshelp             = "Application platform [internal]"
+	rtmetricnumcpu             = "_num_cpu"
+	rtmetricnumcpuhelp         = "Number of CPU [internal]"
+	rtmetricmemalloc           = "_mem_alloc"
+	rtmetricmemallochelp       = "Number of allocated memory for whole app in bytes [internal]"
+	healthcheckfailed          = "_failed_healthchecks"
+	healthcheckfailedhelp      = "Number of failed health checks [internal]"
+	healthcheckstatus          = "_healthcheck_status"
+	healthcheckstatushelp      = "Status of health check: 1 - ok, 0 - failed, -1 - unknown or skipped [internal]"
+	healthcheckduration        = "_healthcheck_duration_seconds"
+	healthcheckdurationhelp    = "Duration of health check calls in seconds [internal]"
+	healthchecktransitions     = "_healthcheck_transitions"
+	healthchecktransitionshelp = "Number of health check status changes [internal]"
+	expvarhelp                 = "Value of expvar variable [expvar]"
+	rtuptime                   = "uptime"
+	rtuptimehelp               = "Application uptime in nanosec [internal]"
+)
+
+const (
+	acceptJSON                   = "application/json"
+	acceptText                   = "text/plain"
+	acceptInflux                 = "influx"
+	formatInflux                 = "influx"
+	formatOpenMetrics            = "openmetrics"
+	charsetUTF8                  = "charset=utf-8"
+	contenttypeJSON              = acceptJSON + "; " + charsetUTF8
+	contenttypeText              = acceptText + "; " + charsetUTF8
+	contenttypePush              = acceptText + "; version=0.0.4; " + charsetUTF8
+	contenttypeHTML              = "text/html; " + charsetUTF8
+	acceptMarkdown               = "text/markdown"
+	contenttypeMarkdown          = acceptMarkdown + "; " + charsetUTF8
+	contenttypeEventStream       = "text/event-stream"
+	acceptOpenMetrics            = "application/openmetrics-text"
+	contenttypeOpenMetrics       = acceptOpenMetrics + "; version=1.0.0; " + charsetUTF8
+	acceptProtobuf               = "application/vnd.google.protobuf"
+	protobufProto                = "proto=io.prometheus.client.MetricFamily"
+	protobufEncoding             = "encoding=delimited"
+	contenttypeProtobufDelimited = acceptProtobuf + "; " + protobufProto + "; " + protobufEncoding
+	defaultPort                  = 9110
 )
 
 const (
-	acceptJSON      = "application/json"
-	acceptText      = "text/plain"
-	charsetUTF8     = "charset=utf-8"
-	contenttypeJSON = acceptJSON + "; " + charsetUTF8
-	contenttypeText = acceptText + "; " + charsetUTF8
-	defaultPort     = 9110
+	dashboardRefresh            = 5 * time.Second
+	dashboardPoints             = 60
+	defaultDrainGrace           = 5 * time.Second
+	defaultCompressionThreshold = 1024
+	defaultStreamInterval       = 5 * time.Second
+	defaultMaxStreams           = 8
+	defaultPushInterval         = 10 * time.Second
+	defaultPushTimeout          = 5 * time.Second
+	maxPushBackoff              = time.Minute
+	minRetryBackoff             = 100 * time.Millisecond
+	defaultPushRetries          = 3
+
+	defaultStatsdAddress = "127.0.0.1:8125"
+	defaultStatsdMTU     = 1432
+
+	defaultGraphiteAddress       = "127.0.0.1:2003"
+	defaultGraphitePickleAddress = "127.0.0.1:2004"
+	defaultGraphiteBuffer        = 100000
+	graphiteBatchSize            = 500
+
+	defaultInfluxURL   = "http://127.0.0.1:8086/write?db=metricer"
+	defaultInfluxBatch = 5000
+
+	defaultOtlpURL = "http://127.0.0.1:4318/v1/metrics"
+	otlpScope      = "go.melnyk.org/metricer"
+
+	defaultRemoteWriteURL   = "http://127.0.0.1:9090/api/v1/write"
+	defaultRemoteWriteQueue = 100000
+	defaultRemoteWriteBatch = 2000
+	remoteWriteVersion      = "0.1.0"
+	contenttypeProtobuf     = "application/x-protobuf"
+
+	snappyMaxLiteral = 1 << 16
+	snappyMaxBlock   = 1 << 16
+	snappyMinMatch   = 4
+	snappyTableBits  = 14
+
+	fileRotationLayout = "20060102T150405.000"
+
+	defaultCheckpointInterval = time.Minute
+	checkpointVersion         = 1
+	checkpointCorruptSuffix   = ".corrupt"
+
+	defaultHistoryResolution = 10 * time.Second
+	defaultHistoryRetention  = time.Hour
+	maxHistorySamples        = 8640 // one day with default resolution
+
+	meterTickInterval = 5 * time.Second
+
+	exemplarMaxLength = 128
+
+	nativeMaxSchema     = 8
+	nativeZeroThreshold = 2.938735877055719e-39 // 2^-128
 )
 
 const (
-//envLocalhostOnly = "METRICER_LOCALHOST_ONLY"
-//envPort          = "METRICER_PORT"
-//envDebug         = "METRICER_DEBUG"
+// envLocalhostOnly = "METRICER_LOCALHOST_ONLY"
+// envPort          = "METRICER_PORT"
+// envDebug         = "METRICER_DEBUG"
 )
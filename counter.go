@@ -3,9 +3,11 @@ package metricer
 import "sync/atomic"
 
 type counter struct {
-	name  string
-	help  string
-	value int64
+	name     string
+	help     string
+	value    int64
+	exemplar atomic.Value // *exemplar
+	metadata
 }
 
 func (metric *counter) Name() string {
@@ -31,3 +33,16 @@ func (metric *counter) Inc(v int64) {
 func (metric *counter) Dec(v int64) {
 	atomic.AddInt64(&metric.value, -v)
 }
+
+// IncWithExemplar increments counter and attaches exemplar labels (e.g. trace_id, span_id) to it
+func (metric *counter) IncWithExemplar(v int64, labels map[string]string) {
+	atomic.AddInt64(&metric.value, v)
+	if e := newExemplar(labels, float64(v)); e != nil {
+		metric.exemplar.Store(e)
+	}
+}
+
+func (metric *counter) lastExemplar() *exemplar {
+	e, _ := metric.exemplar.Load().(*exemplar)
+	return e
+}
@@ -54,3 +54,20 @@ func TestCounterHelp(t *testing.T) {
 		t.Errorf("Expected: help but got %s", val)
 	}
 }
+
+// TestCounterIncWithExemplar validates incrementing counter with exemplar
+func TestCounterIncWithExemplar(t *testing.T) {
+	metric := &counter{value: 50}
+	if e := metric.lastExemplar(); e != nil {
+		t.Errorf("Expected: nil but got %v", e)
+	}
+
+	metric.IncWithExemplar(2, map[string]string{"trace_id": "abc"})
+	if metric.value != 52 {
+		t.Errorf("Expected: 52 but got %d", metric.value)
+	}
+	e := metric.lastExemplar()
+	if e == nil || e.value != 2 || e.labels[0].value != "abc" {
+		t.Errorf("Expected: exemplar but got %v", e)
+	}
+}
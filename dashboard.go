@@ -0,0 +1,267 @@
+package metricer
+
+import (
+	"html/template"
+	"net/http"
+	"sort"
+
+	"go.melnyk.org/mlog"
+)
+
+// dashboardTemplate is self-contained status page without external assets, sparklines are seeded
+// from metrics history when it is enabled and extended by the browser on every refresh
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Metricer</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; margin: 2em; color: #222; }
+h1 { font-size: 20px; }
+h2 { font-size: 16px; margin-top: 2em; }
+table { border-collapse: collapse; }
+th, td { text-align: left; padding: 4px 12px; border-bottom: 1px solid #ddd; }
+td.value { text-align: right; font-family: monospace; }
+.ok { color: #2a7d2a; }
+.failed, .draining { color: #c62828; }
+.skipped, .unknown { color: #888; }
+svg polyline { fill: none; stroke: #1565c0; stroke-width: 1.5; }
+#updated { color: #888; }
+</style>
+</head>
+<body>
+<h1>Metricer</h1>
+
+<h2>Endpoints</h2>
+<ul>
+{{range .Endpoints}}<li><a href="{{.Path}}">{{.Path}}</a> &mdash; {{.Description}}</li>
+{{end}}</ul>
+{{if .Debug}}
+<h2>Profiler</h2>
+<ul>
+<li><a href="debug/pprof/">debug/pprof/</a> &mdash; profiles index</li>
+<li><a href="debug/pprof/goroutine?debug=1">debug/pprof/goroutine</a> &mdash; stack traces of goroutines</li>
+<li><a href="debug/pprof/heap?debug=1">debug/pprof/heap</a> &mdash; memory allocations</li>
+<li><a href="debug/pprof/profile?seconds=30">debug/pprof/profile</a> &mdash; 30 seconds CPU profile</li>
+</ul>
+{{end}}
+<h2>Health</h2>
+<p>Status: <b id="status" class="{{.Status}}">{{.Status}}</b></p>
+<table id="health"><thead><tr><th>Check</th><th>Status</th></tr></thead><tbody>
+{{range .Checks}}<tr><td>{{.Name}}</td><td class="{{.Status}}">{{.Status}}</td></tr>
+{{end}}</tbody></table>
+
+<h2>Metrics</h2>
+<p id="updated"></p>
+<table id="metrics"><thead><tr><th>Name</th><th>Value</th><th>History</th></tr></thead><tbody></tbody></table>
+
+<script>
+(function() {
+	var interval = {{.Interval}};
+	var points = {{.Points}};
+	var statusMetric = {{.StatusMetric}};
+	var history = {{.History}} || {};
+
+	function cell(row, text, className) {
+		var td = document.createElement("td");
+		td.textContent = text;
+		if (className) {
+			td.className = className;
+		}
+		row.appendChild(td);
+		return td;
+	}
+
+	function sparkline(values) {
+		var width = 120, height = 20;
+		var min = Math.min.apply(null, values), max = Math.max.apply(null, values);
+		var coords = values.map(function(v, i) {
+			var x = values.length > 1 ? i * width / (points - 1) : 0;
+			var y = max > min ? height - (v - min) * height / (max - min) : height / 2;
+			return x.toFixed(1) + "," + y.toFixed(1);
+		});
+		var svg = document.createElementNS("http://www.w3.org/2000/svg", "svg");
+		svg.setAttribute("width", width);
+		svg.setAttribute("height", height);
+		var line = document.createElementNS("http://www.w3.org/2000/svg", "polyline");
+		line.setAttribute("points", coords.join(" "));
+		svg.appendChild(line);
+		return svg;
+	}
+
+	// health is rendered from status gauges of the last checks, so checks are not run by the page
+	function renderHealth(metrics, draining) {
+		var statuses = {"1": "ok", "0": "failed"};
+		var prefix = statusMetric + "{check=\"";
+		var checks = {};
+		var overall = "ok";
+		Object.keys(metrics).forEach(function(key) {
+			if (key.indexOf(prefix) !== 0) {
+				return;
+			}
+			var status = statuses[String(metrics[key])] || "unknown";
+			checks[key.slice(prefix.length, -2)] = status;
+			if (status === "failed" || (status === "unknown" && overall === "ok")) {
+				overall = status;
+			}
+		});
+
+		// instance in drain mode is reported as failed by health check regardless of checks
+		if (draining) {
+			overall = "draining";
+		}
+
+		var status = document.getElementById("status");
+		status.textContent = overall;
+		status.className = overall;
+
+		var body = document.querySelector("#health tbody");
+		body.innerHTML = "";
+		Object.keys(checks).sort().forEach(function(name) {
+			var row = document.createElement("tr");
+			cell(row, name);
+			cell(row, checks[name], checks[name]);
+			body.appendChild(row);
+		});
+	}
+
+	function renderMetrics(data) {
+		var metrics = data.metrics || {};
+		var body = document.querySelector("#metrics tbody");
+		body.innerHTML = "";
+		Object.keys(metrics).sort().forEach(function(name) {
+			var value = metrics[name];
+			var key = name;
+			// histograms are shown by number of observations
+			if (typeof value === "object") {
+				value = value.count;
+				key = name.replace(/^([^{]*)/, "$1_count");
+			}
+
+			var values = history[key] = (history[key] || []).concat([value]).slice(-points);
+
+			var row = document.createElement("tr");
+			cell(row, name);
+			cell(row, String(value), "value");
+			cell(row, "").appendChild(sparkline(values.map(Number).filter(isFinite)));
+			body.appendChild(row);
+		});
+		renderHealth(metrics, data.draining);
+		document.getElementById("updated").textContent = "Updated at " + new Date().toLocaleTimeString();
+	}
+
+	function refresh() {
+		var json = {headers: {"Accept": "application/json"}};
+		fetch("metrics/values", json).then(function(r) { return r.json(); }).then(renderMetrics).catch(function() {});
+	}
+
+	refresh();
+	setInterval(refresh, interval);
+})();
+</script>
+</body>
+</html>
+`))
+
+type dashboardEndpoint struct {
+	Path        string
+	Description string
+}
+
+type dashboardCheck struct {
+	Name   string
+	Status string
+}
+
+func (h *host) dashboard(w http.ResponseWriter, r *http.Request) {
+	if !h.enterHandler(w, r) {
+		return
+	}
+	defer h.wg.Done()
+
+	// only GET and HEAD methods are allowed
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		h.handlerError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	h.log.Event(mlog.Verbose, func(e mlog.Event) {
+		e.String("msg", "Get dashboard")
+		e.String("remote", r.RemoteAddr)
+	})
+
+	// relative links keep the page working behind reverse proxy with path prefix
+	endpoints := []dashboardEndpoint{
+		{pathHealthCheck[1:], "health check status"},
+		{pathHealthGrpc[1:], "health status in gRPC protocol"},
+		{pathMetricsValues[1:], "metrics values"},
+		{pathMetricsCatalog[1:] + "?format=markdown", "metrics catalog"},
+	}
+	if h.config.EnableDebug {
+		endpoints = append(endpoints,
+			dashboardEndpoint{pathDebugVars[1:], "expvar variables"},
+			dashboardEndpoint{pathDebugHealthHistory[1:], "health check status changes"},
+			dashboardEndpoint{pathDebugHealthDrain[1:], "drain mode"},
+			dashboardEndpoint{pathDebugLoggerLevels[1:], "logger levels"},
+		)
+	}
+
+	if h.history != nil {
+		endpoints = append(endpoints, dashboardEndpoint{pathMetricsHistory[1:] + "?name=" + rtuptime, "metrics history"})
+	}
+
+	// statuses of the last checks, health checks are not run by the page
+	h.mu.RLock()
+	checks := make([]dashboardCheck, 0, len(h.healthchecks))
+	status := healthOk
+	for _, v := range h.healthchecks {
+		checks = append(checks, dashboardCheck{Name: v.Name(), Status: v.status().String()})
+		switch v.status() {
+		case healthOk:
+		case healthFailed:
+			status = healthFailed
+		default:
+			if status == healthOk {
+				status = healthUnknown
+			}
+		}
+	}
+	h.mu.RUnlock()
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].Name < checks[j].Name
+	})
+
+	// health check fails in drain mode, so it is shown instead of statuses of checks
+	overall := status.String()
+	if h.isDraining() {
+		overall = "draining"
+	}
+
+	var history map[string][]jsonFloat
+	if h.history != nil {
+		history = h.history.recent(dashboardPoints)
+	}
+
+	data := struct {
+		Endpoints    []dashboardEndpoint
+		Debug        bool
+		Interval     int // milliseconds
+		Points       int
+		Status       string
+		Checks       []dashboardCheck
+		StatusMetric string
+		History      map[string][]jsonFloat
+	}{
+		Endpoints:    endpoints,
+		Debug:        h.config.EnableDebug,
+		Interval:     int(dashboardRefresh.Seconds() * 1000),
+		Points:       dashboardPoints,
+		Status:       overall,
+		Checks:       checks,
+		StatusMetric: healthcheckstatus,
+		History:      history,
+	}
+
+	w.Header().Set("Content-Type", contenttypeHTML)
+	dashboardTemplate.Execute(w, data)
+}
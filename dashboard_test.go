@@ -0,0 +1,139 @@
+package metricer
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"go.melnyk.org/mlog/testlog"
+)
+
+func TestDashboard(t *testing.T) {
+	tests := []struct {
+		debug bool
+		pprof bool
+	}{
+		{false, false},
+		{true, true},
+	}
+
+	for i, v := range tests {
+		mhost := NewHost(&Config{EnableDebug: v.debug}, testlog.NewLogbook()).(*host)
+		muxer := mhost.buildMuxer()
+
+		req := httptest.NewRequest("GET", "http://test/", nil)
+		w := httptest.NewRecorder()
+		muxer.ServeHTTP(w, req)
+
+		resp := w.Result()
+		body, _ := ioutil.ReadAll(resp.Body)
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected (%d): %d, but got %d", i, http.StatusOK, resp.StatusCode)
+		}
+		if resp.Header.Get("Content-Type") != contenttypeHTML {
+			t.Errorf("Expected (%d): Content-type HTML, but got %s", i, resp.Header.Get("Content-Type"))
+		}
+		if !strings.Contains(string(body), `href="metrics/values"`) || !strings.Contains(string(body), `href="health/check"`) {
+			t.Errorf("Expected (%d): endpoints list, but got %s", i, body)
+		}
+		if strings.Contains(string(body), `href="debug/pprof/"`) != v.pprof {
+			t.Errorf("Expected (%d): pprof links %t, but got %s", i, v.pprof, body)
+		}
+		if strings.Contains(string(body), "<link") || strings.Contains(string(body), "url(") || strings.Contains(string(body), "src=") {
+			t.Errorf("Expected (%d): no external assets, but got %s", i, body)
+		}
+	}
+}
+
+func TestDashboardOnlyRoot(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	muxer := mhost.buildMuxer()
+
+	tests := []struct {
+		method      string
+		url         string
+		code        int
+		contenttype string
+	}{
+		{"GET", "http://test/", http.StatusOK, contenttypeHTML},
+		{"HEAD", "http://test/", http.StatusOK, contenttypeHTML},
+		{"POST", "http://test/", http.StatusMethodNotAllowed, contenttypeJSON},
+		{"GET", "http://test/index.html", http.StatusNotFound, contenttypeJSON},
+	}
+
+	for i, v := range tests {
+		req := httptest.NewRequest(v.method, v.url, nil)
+		w := httptest.NewRecorder()
+		muxer.ServeHTTP(w, req)
+
+		resp := w.Result()
+		if resp.StatusCode != v.code {
+			t.Errorf("Expected (%d): %d, but got %d", i, v.code, resp.StatusCode)
+		}
+		if resp.Header.Get("Content-Type") != v.contenttype {
+			t.Errorf("Expected (%d): %s, but got %s", i, v.contenttype, resp.Header.Get("Content-Type"))
+		}
+	}
+}
+
+func TestDashboardCachedHealth(t *testing.T) {
+	var calls int32
+	mhost := NewHost(&Config{History: &HistoryConfig{}}, testlog.NewLogbook()).(*host)
+	mhost.NewCounter("requests", "requests help").Inc(2)
+	mhost.NewHealthCheck("db", "db help", func() error {
+		atomic.AddInt32(&calls, 1)
+		return errors.New("failed")
+	})
+	mhost.refreshHealth()
+	mhost.history.push(mhost.collect())
+
+	req := httptest.NewRequest("GET", "http://test/", nil)
+	w := httptest.NewRecorder()
+	mhost.dashboard(w, req)
+	body := w.Body.String()
+
+	// statuses of the last checks are rendered, checks are run neither by the page nor by its script
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Expected: 1 health check run, but got %d", n)
+	}
+	if !strings.Contains(body, `<tr><td>db</td><td class="failed">failed</td></tr>`) || !strings.Contains(body, `<b id="status" class="failed">failed</b>`) {
+		t.Errorf("Expected: cached health status, but got %s", body)
+	}
+	if strings.Contains(body, `fetch("health/check"`) {
+		t.Errorf("Expected: health checks are not requested by script, but got %s", body)
+	}
+
+	// sparklines are seeded from history
+	if !strings.Contains(body, `"requests":[2]`) {
+		t.Errorf("Expected: history of requests, but got %s", body)
+	}
+}
+
+func TestDashboardDraining(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewHealthCheck("db", "db help", func() error { return nil })
+	mhost.refreshHealth()
+	mhost.SetDraining(true)
+	muxer := mhost.buildMuxer()
+
+	req := httptest.NewRequest("GET", "http://test/", nil)
+	w := httptest.NewRecorder()
+	muxer.ServeHTTP(w, req)
+	if body := w.Body.String(); !strings.Contains(body, `<b id="status" class="draining">draining</b>`) {
+		t.Errorf("Expected: draining status, but got %s", body)
+	}
+
+	// drain mode is passed to the script with metrics values
+	req = httptest.NewRequest("GET", "http://test/metrics/values", nil)
+	req.Header.Set("Accept", "application/json")
+	w = httptest.NewRecorder()
+	muxer.ServeHTTP(w, req)
+	if body := w.Body.String(); !strings.Contains(body, `"draining":true`) {
+		t.Errorf("Expected: draining in metrics values, but got %s", body)
+	}
+}
@@ -6,4 +6,24 @@ var (
 	errNilConfig          = errors.New("Config cannot be nil")
 	errRunMoreOnce        = errors.New("Metricer start function is called more than once")
 	errHealthCheckerPanic = errors.New("Panic in health check callback")
+
+	errHealthCheckExists       = errors.New("Health check with the same name already exists")
+	errHealthDependencyMissing = errors.New("Health check dependency does not exist")
+	errHealthDependencyCycle   = errors.New("Health check dependency cycle")
+
+	errPushJobEmpty     = errors.New("Job name cannot be empty")
+	errPushLabelInvalid = errors.New("Grouping label name is invalid")
+
+	errFilePathEmpty     = errors.New("File path cannot be empty")
+	errFileFormatUnknown = errors.New("File format is unknown")
+
+	errCheckpointPathEmpty = errors.New("Checkpoint path cannot be empty")
+	errCheckpointCorrupt   = errors.New("Checkpoint file is corrupted")
+	errCheckpointVersion   = errors.New("Checkpoint file version is not supported")
+
+	errExpvarExists = errors.New("Expvar variable with the same name already exists")
+
+	errHistoryRetention = errors.New("History retention cannot be less than resolution")
+
+	errCatalogFormatUnknown = errors.New("Catalog format is unknown")
 )
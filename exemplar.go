@@ -0,0 +1,44 @@
+package metricer
+
+import (
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// exemplar represents reference to external data (e.g. trace) attached to the latest sample
+type exemplar struct {
+	labels []labelPair // sorted by name
+	value  float64
+	time   time.Time
+}
+
+// newExemplar creates exemplar, nil is returned when labels exceed the length allowed by OpenMetrics
+func newExemplar(labels map[string]string, value float64) *exemplar {
+	e := &exemplar{
+		labels: make([]labelPair, 0, len(labels)),
+		value:  value,
+		time:   time.Now(),
+	}
+
+	length := 0
+	for k, v := range labels {
+		length += utf8.RuneCountInString(k) + utf8.RuneCountInString(v)
+		e.labels = append(e.labels, labelPair{name: k, value: v})
+	}
+	if length > exemplarMaxLength {
+		return nil
+	}
+
+	sort.Slice(e.labels, func(i, j int) bool {
+		return e.labels[i].name < e.labels[j].name
+	})
+	return e
+}
+
+// String formats exemplar in OpenMetrics format, e.g. # {trace_id="abc"} 1 1700000000.123
+func (e *exemplar) String() string {
+	return " # " + formatLabels(e.labels) + " " + formatValue(e.value) + " " +
+		strconv.FormatFloat(float64(e.time.UnixNano())/1e9, 'f', 3, 64)
+}
@@ -0,0 +1,32 @@
+package metricer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewExemplar(t *testing.T) {
+	e := newExemplar(map[string]string{"trace_id": "abc", "span_id": "def"}, 0.5)
+	if e == nil {
+		t.Fatal("Expected: exemplar, but got nil")
+	}
+	if e.labels[0].name != "span_id" || e.labels[1].name != "trace_id" {
+		t.Errorf("Expected: sorted labels, but got %v", e.labels)
+	}
+
+	if e := newExemplar(map[string]string{"trace_id": strings.Repeat("a", 121)}, 1); e != nil {
+		t.Errorf("Expected: nil for too long labels, but got %v", e)
+	}
+}
+
+func TestExemplarString(t *testing.T) {
+	e := &exemplar{
+		labels: []labelPair{{name: "trace_id", value: "abc"}},
+		value:  0.5,
+		time:   time.Unix(1700000000, 123000000),
+	}
+	if result := e.String(); result != ` # {trace_id="abc"} 0.5 1700000000.123` {
+		t.Errorf("Expected: exemplar in OpenMetrics format, but got %s", result)
+	}
+}
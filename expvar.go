@@ -0,0 +1,76 @@
+package metricer
+
+import (
+	"expvar"
+	"regexp"
+	"sort"
+)
+
+// familyCollector is implemented by metrics producing families at the moment of collection
+type familyCollector interface {
+	families() []family
+}
+
+var invalidNameRegexp = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+
+// expvarCollector exposes expvar variables as gauges, Int and Float variables become gauges
+// with the same name, Int and Float items of Map variables become series labeled with key
+type expvarCollector struct {
+	prefix string
+}
+
+func (collector *expvarCollector) families() []family {
+	var families []family
+
+	expvar.Do(func(kv expvar.KeyValue) {
+		f := family{name: collector.prefix + invalidNameRegexp.ReplaceAllString(kv.Key, "_"), help: expvarhelp, kind: kindGauge}
+
+		switch v := kv.Value.(type) {
+		case *expvar.Int:
+			f.series = []series{{value: float64(v.Value())}}
+		case *expvar.Float:
+			f.series = []series{{value: v.Value()}}
+		case *expvar.Map:
+			v.Do(func(item expvar.KeyValue) {
+				key := []labelPair{{name: "key", value: item.Key}}
+				switch item := item.Value.(type) {
+				case *expvar.Int:
+					f.series = append(f.series, series{labels: key, value: float64(item.Value())})
+				case *expvar.Float:
+					f.series = append(f.series, series{labels: key, value: item.Value()})
+				}
+			})
+		}
+
+		if len(f.series) > 0 {
+			families = append(families, f)
+		}
+	})
+
+	sort.SliceStable(families, func(i, j int) bool {
+		return families[i].name < families[j].name
+	})
+	return families
+}
+
+// CollectExpvar exposes expvar Int, Float and Map variables as gauges, prefix is added to their names
+func (h *host) CollectExpvar(prefix string) {
+	source := callSite()
+	collector := &expvarCollector{prefix: prefix}
+	h.mu.Lock()
+	h.metrics = append(h.metrics, collector)
+	h.sources[collector] = source
+	h.mu.Unlock()
+}
+
+// PublishExpvar publishes values of all metrics under the name in expvar, so they are available at /debug/vars
+func (h *host) PublishExpvar(name string) error {
+	if expvar.Get(name) != nil {
+		return errExpvarExists
+	}
+
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return jsonMetrics(h.collect())
+	}))
+	return nil
+}
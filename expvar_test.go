@@ -0,0 +1,108 @@
+package metricer
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"go.melnyk.org/mlog/testlog"
+)
+
+// expvar variables are global, existing ones are reused when tests run more than once
+
+func expvarInt(name string) *expvar.Int {
+	if v, ok := expvar.Get(name).(*expvar.Int); ok {
+		return v
+	}
+	return expvar.NewInt(name)
+}
+
+func expvarFloat(name string) *expvar.Float {
+	if v, ok := expvar.Get(name).(*expvar.Float); ok {
+		return v
+	}
+	return expvar.NewFloat(name)
+}
+
+func expvarMap(name string) *expvar.Map {
+	if v, ok := expvar.Get(name).(*expvar.Map); ok {
+		return v.Init()
+	}
+	return expvar.NewMap(name)
+}
+
+var expvarPublished int32 // number of published test variables, keeps names unique
+
+func TestCollectExpvar(t *testing.T) {
+	expvarInt("test.requests").Set(5)
+	expvarFloat("test_ratio").Set(0.25)
+	m := expvarMap("test_map")
+	m.Add("a", 2)
+	m.AddFloat("b", 1.5)
+	m.Set("c", expvar.Func(func() interface{} { return "skipped" }))
+
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.CollectExpvar("expvar_")
+
+	values := make(map[string]float64)
+	for _, f := range mhost.collect().families {
+		if f.kind != kindGauge {
+			continue
+		}
+		for _, s := range f.series {
+			values[f.name+formatLabels(s.labels)] = s.value
+		}
+	}
+
+	tests := []struct {
+		name  string
+		value float64
+	}{
+		{"expvar_test_requests{}", 5},
+		{"expvar_test_ratio{}", 0.25},
+		{`expvar_test_map{key="a"}`, 2},
+		{`expvar_test_map{key="b"}`, 1.5},
+	}
+
+	for i, v := range tests {
+		if value, ok := values[v.name]; !ok || value != v.value {
+			t.Errorf("Expected (%d): %s = %f, but got %f", i, v.name, v.value, value)
+		}
+	}
+	if _, ok := values[`expvar_test_map{key="c"}`]; ok {
+		t.Error("Expected: not numeric items are skipped")
+	}
+	if _, ok := values["expvar_memstats{}"]; ok {
+		t.Error("Expected: functions are skipped")
+	}
+}
+
+func TestPublishExpvar(t *testing.T) {
+	mhost := NewHost(&Config{EnableDebug: true}, testlog.NewLogbook()).(*host)
+	mhost.NewCounter("published", "published help").Inc(3)
+
+	name := fmt.Sprintf("metricer_test_%d", atomic.AddInt32(&expvarPublished, 1))
+	if err := mhost.PublishExpvar(name); err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+	if err := mhost.PublishExpvar(name); err != errExpvarExists {
+		t.Errorf("Expected: %v, but got %v", errExpvarExists, err)
+	}
+
+	req := httptest.NewRequest("GET", "http://test/debug/vars", nil)
+	w := httptest.NewRecorder()
+	mhost.buildMuxer().ServeHTTP(w, req)
+
+	var vars map[string]json.RawMessage
+	if err := json.NewDecoder(w.Body).Decode(&vars); err != nil {
+		t.Fatalf("Expected: JSON, but got %s", err.Error())
+	}
+	var metrics map[string]interface{}
+	json.Unmarshal(vars[name], &metrics)
+	if metrics["published"] != 3.0 {
+		t.Errorf("Expected: 3, but got %v", metrics["published"])
+	}
+}
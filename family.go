@@ -0,0 +1,222 @@
+package metricer
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type metricKind int
+
+const (
+	kindCounter metricKind = iota
+	kindGauge
+	kindHistogram
+)
+
+func (kind metricKind) String() string {
+	switch kind {
+	case kindCounter:
+		return "counter"
+	case kindGauge:
+		return "gauge"
+	case kindHistogram:
+		return "histogram"
+	default:
+		return "untyped"
+	}
+}
+
+type labelPair struct {
+	name  string
+	value string
+}
+
+// series represents one value of metric family with its own labels
+type series struct {
+	labels []labelPair
+	value  float64
+	hist   *histogramSnapshot // histograms only
+
+	exemplar *exemplar // counters only
+}
+
+// family represents group of series with the same name, help and type
+type family struct {
+	name   string
+	help   string
+	unit   string
+	kind   metricKind
+	series []series
+}
+
+// snapshot represents state of all metrics at the moment of collection
+type snapshot struct {
+	started  time.Time // time of host initialization
+	time     time.Time
+	labels   []labelPair // host labels, attached to every series
+	families []family
+}
+
+// collect builds snapshot of all registered metrics
+func (h *host) collect() *snapshot {
+	h.mu.RLock()
+	metrics := make([]interface{}, len(h.metrics))
+	copy(metrics, h.metrics)
+	healthchecks := make([]*health, len(h.healthchecks))
+	copy(healthchecks, h.healthchecks)
+	h.mu.RUnlock()
+
+	snap := &snapshot{
+		started: h.started,
+		time:    time.Now(),
+		labels:  make([]labelPair, 0),
+	}
+
+	// series of metrics with the same name are grouped into one family
+	index := make(map[string]int)
+	add := func(f family) {
+		if i, ok := index[f.name]; ok && snap.families[i].kind == f.kind {
+			snap.families[i].series = append(snap.families[i].series, f.series...)
+			return
+		}
+		index[f.name] = len(snap.families)
+		snap.families = append(snap.families, f)
+	}
+
+	for _, v := range metrics {
+		meta := metadataOf(v)
+		if meta.deprecated() && h.config.HideDeprecated {
+			continue
+		}
+
+		var f family
+		switch v := v.(type) {
+		case Label:
+			snap.labels = append(snap.labels, labelPair{name: v.Name(), value: v.Value()})
+			continue
+		case Counter:
+			s := series{value: float64(v.Count())}
+			if c, ok := v.(*counter); ok {
+				s.exemplar = c.lastExemplar()
+			}
+			f = family{name: v.Name(), help: v.Help(), kind: kindCounter, series: []series{s}}
+		case Gauge:
+			f = family{name: v.Name(), help: v.Help(), kind: kindGauge, series: []series{{value: float64(v.Value())}}}
+		case *histogram:
+			f = family{name: v.Name(), help: v.Help(), kind: kindHistogram, series: []series{{hist: v.snapshot()}}}
+		case *meter:
+			f = v.family(snap.time)
+		case familyCollector:
+			for _, f := range v.families() {
+				add(f)
+			}
+			continue
+		default:
+			continue
+		}
+
+		f.help = meta.describe(f.help)
+		f.unit = meta.unit
+		add(f)
+	}
+
+	for _, f := range healthFamilies(healthchecks) {
+		add(f)
+	}
+
+	add(family{name: rtuptime, help: rtuptimehelp, kind: kindGauge, series: []series{{value: float64(snap.time.Sub(h.started))}}})
+
+	return snap
+}
+
+// healthFamilies builds status, duration and transitions families for health checks
+func healthFamilies(healthchecks []*health) []family {
+	if len(healthchecks) == 0 {
+		return nil
+	}
+
+	status := family{name: healthcheckstatus, help: healthcheckstatushelp, kind: kindGauge}
+	duration := family{name: healthcheckduration, help: healthcheckdurationhelp, kind: kindHistogram}
+	transitions := family{name: healthchecktransitions, help: healthchecktransitionshelp, kind: kindCounter}
+
+	for _, v := range healthchecks {
+		check := labelPair{name: "check", value: v.Name()}
+
+		status.series = append(status.series, series{labels: []labelPair{check}, value: v.status().value()})
+		duration.series = append(duration.series, series{labels: []labelPair{check}, hist: v.duration.snapshot()})
+
+		for from := healthUnknown; from < healthStatusCount; from++ {
+			for to := healthUnknown; to < healthStatusCount; to++ {
+				if count := v.transitionsCount(from, to); count > 0 {
+					transitions.series = append(transitions.series, series{
+						labels: []labelPair{check, {name: "from", value: from.String()}, {name: "to", value: to.String()}},
+						value:  float64(count),
+					})
+				}
+			}
+		}
+	}
+
+	families := []family{status, duration}
+	if len(transitions.series) > 0 {
+		families = append(families, transitions)
+	}
+
+	return families
+}
+
+// samples calls fn for every sample of the snapshot, histograms are split into _bucket, _sum and _count samples
+func (snap *snapshot) samples(fn func(name string, labels []labelPair, value float64)) {
+	for _, f := range snap.families {
+		for _, s := range f.series {
+			if s.hist == nil {
+				fn(f.name, s.labels, s.value)
+				continue
+			}
+
+			// the copy of series labels extended with bucket bound
+			le := func(bound string) []labelPair {
+				return append(s.labels[:len(s.labels):len(s.labels)], labelPair{name: "le", value: bound})
+			}
+			for i, bound := range s.hist.buckets {
+				fn(f.name+"_bucket", le(formatValue(bound)), float64(s.hist.counts[i]))
+			}
+			fn(f.name+"_bucket", le("+Inf"), float64(s.hist.count))
+			fn(f.name+"_sum", s.labels, s.hist.sum)
+			fn(f.name+"_count", s.labels, float64(s.hist.count))
+		}
+	}
+}
+
+// formatValue formats sample value, integer values are written without exponent
+func formatValue(v float64) string {
+	if v == math.Trunc(v) && math.Abs(v) < 1e18 {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabels formats labels in the text exposition format, e.g. {a="b",c="d"}
+func formatLabels(labels ...[]labelPair) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	first := true
+	for _, list := range labels {
+		for _, v := range list {
+			if !first {
+				b.WriteByte(',')
+			}
+			first = false
+			b.WriteString(v.name)
+			b.WriteString(`="`)
+			labelValueEscaper.WriteString(&b, v.value)
+			b.WriteByte('"')
+		}
+	}
+	b.WriteByte('}')
+	return b.String()
+}
@@ -0,0 +1,267 @@
+package metricer
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Formats of file sink
+const (
+	FileFormatNDJSON = "ndjson"
+	FileFormatCSV    = "csv"
+)
+
+// FileConfig represents configuration of file sink writing metrics snapshots to local files
+type FileConfig struct {
+	// Path of the current file, rotated files get timestamp suffix
+	Path string `json:"path,omitempty" yaml:"path"`
+
+	// Format of the file, ndjson or csv
+	Format string `json:"format,omitempty" yaml:"format"`
+
+	// Interval between snapshots
+	Interval time.Duration `json:"interval,omitempty" yaml:"interval"`
+
+	// MaxSize of the current file in bytes before rotation, zero disables size based rotation
+	MaxSize int64 `json:"max_size,omitempty" yaml:"max_size"`
+
+	// MaxAge of the current file before rotation, zero disables age based rotation
+	MaxAge time.Duration `json:"max_age,omitempty" yaml:"max_age"`
+
+	// Gzip enables compression of rotated files
+	Gzip bool `json:"gzip,omitempty" yaml:"gzip"`
+
+	// Retention is number of rotated files kept on disk, zero keeps all files
+	Retention int `json:"retention,omitempty" yaml:"retention"`
+}
+
+// Validate checks file sink config structure
+func (config *FileConfig) Validate() error {
+	if config.Path == "" {
+		return errFilePathEmpty
+	}
+	switch config.Format {
+	case "":
+		config.Format = FileFormatNDJSON
+	case FileFormatNDJSON, FileFormatCSV:
+	default:
+		return errFileFormatUnknown
+	}
+	if config.Interval <= 0 {
+		config.Interval = defaultPushInterval
+	}
+	return nil
+}
+
+type fileRecord struct {
+	Time   time.Time         `json:"time"`
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Value  jsonFloat         `json:"value"`
+}
+
+type fileSink struct {
+	config FileConfig
+	file   *os.File
+	size   int64
+	opened time.Time // time of opening the current file
+}
+
+func newFileSink(config FileConfig) *fileSink {
+	return &fileSink{config: config}
+}
+
+func (exporter *fileSink) name() string {
+	return "file"
+}
+
+func (exporter *fileSink) interval() time.Duration {
+	return exporter.config.Interval
+}
+
+func (exporter *fileSink) close() error {
+	if exporter.file == nil {
+		return nil
+	}
+	err := exporter.file.Close()
+	exporter.file = nil
+	return err
+}
+
+func (exporter *fileSink) push(snap *snapshot) error {
+	if exporter.file != nil && exporter.expired(snap.time) {
+		if err := exporter.rotate(snap.time); err != nil {
+			return err
+		}
+	}
+
+	if exporter.file == nil {
+		if err := exporter.open(snap.time); err != nil {
+			return err
+		}
+	}
+
+	n, err := exporter.file.Write(exporter.format(snap))
+	exporter.size += int64(n)
+	return err
+}
+
+// expired checks whether the current file should be rotated
+func (exporter *fileSink) expired(now time.Time) bool {
+	if exporter.config.MaxSize > 0 && exporter.size >= exporter.config.MaxSize {
+		return true
+	}
+	if exporter.config.MaxAge > 0 && now.Sub(exporter.opened) >= exporter.config.MaxAge {
+		return true
+	}
+	return false
+}
+
+// open opens the current file for appending, CSV header is written into the new file
+func (exporter *fileSink) open(now time.Time) error {
+	file, err := os.OpenFile(exporter.config.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	exporter.file = file
+	exporter.size = info.Size()
+	exporter.opened = now
+
+	if exporter.size == 0 && exporter.config.Format == FileFormatCSV {
+		n, err := io.WriteString(file, "time,name,labels,value\n")
+		exporter.size += int64(n)
+		return err
+	}
+	return nil
+}
+
+// format converts snapshot into lines of the configured format, host labels are written with series labels
+func (exporter *fileSink) format(snap *snapshot) []byte {
+	var b strings.Builder
+
+	if exporter.config.Format == FileFormatCSV {
+		timestamp := snap.time.Format(time.RFC3339Nano)
+		w := csv.NewWriter(&b)
+		snap.samples(func(name string, labels []labelPair, value float64) {
+			w.Write([]string{timestamp, name, formatLabels(snap.labels, labels), formatValue(value)})
+		})
+		w.Flush()
+		return []byte(b.String())
+	}
+
+	enc := json.NewEncoder(&b)
+	snap.samples(func(name string, labels []labelPair, value float64) {
+		record := fileRecord{Time: snap.time, Name: name, Labels: make(map[string]string), Value: jsonFloat(value)}
+		for _, list := range [][]labelPair{snap.labels, labels} {
+			for _, v := range list {
+				record.Labels[v.name] = v.value
+			}
+		}
+		enc.Encode(record)
+	})
+	return []byte(b.String())
+}
+
+// rotate renames the current file using timestamp suffix, compresses it and removes the oldest rotated files
+func (exporter *fileSink) rotate(now time.Time) error {
+	if err := exporter.close(); err != nil {
+		return err
+	}
+
+	rotated := exporter.config.Path + "." + now.UTC().Format(fileRotationLayout)
+	if err := os.Rename(exporter.config.Path, rotated); err != nil {
+		return err
+	}
+
+	if exporter.config.Gzip {
+		if err := gzipFile(rotated); err != nil {
+			return err
+		}
+	}
+
+	return exporter.prune()
+}
+
+// prune removes rotated files above retention count
+func (exporter *fileSink) prune() error {
+	if exporter.config.Retention <= 0 {
+		return nil
+	}
+
+	files, err := exporter.rotatedFiles()
+	if err != nil {
+		return err
+	}
+
+	for len(files) > exporter.config.Retention {
+		if err := os.Remove(files[0]); err != nil {
+			return err
+		}
+		files = files[1:]
+	}
+	return nil
+}
+
+// rotatedFiles lists files created by rotation in chronological order, other files sharing the path prefix are skipped
+func (exporter *fileSink) rotatedFiles() ([]string, error) {
+	matches, err := filepath.Glob(exporter.config.Path + ".*")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, v := range matches {
+		suffix := strings.TrimSuffix(strings.TrimPrefix(v, exporter.config.Path+"."), ".gz")
+		if _, err := time.Parse(fileRotationLayout, suffix); err == nil {
+			files = append(files, v)
+		}
+	}
+
+	// timestamp suffixes are sorted in chronological order
+	sort.Strings(files)
+	return files, nil
+}
+
+// gzipFile replaces the file with its compressed copy
+func gzipFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if err == nil {
+		err = zw.Close()
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+
+	src.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,202 @@
+package metricer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.melnyk.org/mlog/testlog"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "metricer")
+	if err != nil {
+		t.Fatalf("Expected: temporary directory, but got %s", err.Error())
+	}
+	return dir
+}
+
+func TestFileConfig(t *testing.T) {
+	tests := []struct {
+		config FileConfig
+		err    error
+	}{
+		{FileConfig{Path: "metrics.ndjson"}, nil},
+		{FileConfig{Path: "metrics.csv", Format: FileFormatCSV}, nil},
+		{FileConfig{}, errFilePathEmpty},
+		{FileConfig{Path: "metrics.xml", Format: "xml"}, errFileFormatUnknown},
+	}
+
+	for i, v := range tests {
+		if err := v.config.Validate(); err != v.err {
+			t.Errorf("Expected (%d): %v, but got %v", i, v.err, err)
+		}
+	}
+}
+
+func TestFileSinkNDJSON(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewCounter("counter", "counter help").Inc(3)
+	mhost.registerHistogram("histogram", "histogram help", []float64{1}).Observe(2)
+
+	config := FileConfig{Path: filepath.Join(dir, "metrics.ndjson")}
+	config.Validate()
+	exporter := newFileSink(config)
+	if err := exporter.push(mhost.collect()); err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+	exporter.close()
+
+	file, _ := os.Open(config.Path)
+	defer file.Close()
+
+	found := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record fileRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Expected: JSON line, but got %s", scanner.Text())
+		}
+		if record.Labels[rtmetricos] != goos {
+			t.Errorf("Expected: host labels, but got %v", record.Labels)
+		}
+		switch {
+		case record.Name == "counter" && record.Value == 3:
+			found++
+		case record.Name == "histogram_bucket" && record.Labels["le"] == "+Inf" && record.Value == 1:
+			found++
+		case record.Name == "histogram_sum" && record.Value == 2:
+			found++
+		}
+	}
+	if found != 3 {
+		t.Errorf("Expected: 3 records, but got %d", found)
+	}
+}
+
+func TestFileSinkCSV(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewCounter("counter", "counter help").Inc(3)
+
+	config := FileConfig{Path: filepath.Join(dir, "metrics.csv"), Format: FileFormatCSV}
+	config.Validate()
+	exporter := newFileSink(config)
+	exporter.push(mhost.collect())
+	exporter.push(mhost.collect())
+	exporter.close()
+
+	file, _ := os.Open(config.Path)
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected: CSV file, but got %s", err.Error())
+	}
+
+	if strings.Join(records[0], ",") != "time,name,labels,value" {
+		t.Errorf("Expected: CSV header, but got %v", records[0])
+	}
+
+	found := 0
+	for _, v := range records[1:] {
+		if v[0] == "time" {
+			t.Error("Expected: header only once")
+		}
+		if v[1] == "counter" {
+			found++
+			if v[2] != `{_os="`+goos+`"}` || v[3] != "3" {
+				t.Errorf("Expected: counter record, but got %v", v)
+			}
+		}
+	}
+	if found != 2 {
+		t.Errorf("Expected: 2 counter records, but got %d", found)
+	}
+}
+
+func TestFileSinkRotation(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+
+	config := FileConfig{Path: filepath.Join(dir, "metrics.ndjson"), MaxSize: 1, Gzip: true, Retention: 2}
+	config.Validate()
+
+	// files sharing the path prefix are not created by the sink and must survive pruning
+	unrelated := []string{config.Path + ".1", config.Path + ".BAK", config.Path + ".tmp123"}
+	for _, v := range unrelated {
+		if err := ioutil.WriteFile(v, []byte("keep"), 0644); err != nil {
+			t.Fatalf("Expected: no errors, but got %s", err.Error())
+		}
+	}
+
+	exporter := newFileSink(config)
+	defer exporter.close()
+
+	// every push rotates the previous file due to the size limit
+	for i := 0; i < 4; i++ {
+		snap := mhost.collect()
+		snap.time = snap.time.Add(time.Duration(i) * time.Second)
+		if err := exporter.push(snap); err != nil {
+			t.Fatalf("Expected: no errors, but got %s", err.Error())
+		}
+	}
+
+	rotated, _ := exporter.rotatedFiles()
+	if len(rotated) != 2 {
+		t.Fatalf("Expected: 2 rotated files, but got %v", rotated)
+	}
+	for _, v := range rotated {
+		if !strings.HasSuffix(v, ".gz") {
+			t.Errorf("Expected: compressed file, but got %s", v)
+		}
+	}
+	if _, err := os.Stat(config.Path); err != nil {
+		t.Errorf("Expected: current file, but got %s", err.Error())
+	}
+	for _, v := range unrelated {
+		if _, err := os.Stat(v); err != nil {
+			t.Errorf("Expected: unrelated file kept, but got %s", err.Error())
+		}
+	}
+}
+
+func TestFileSinkRotationByAge(t *testing.T) {
+	exporter := newFileSink(FileConfig{MaxAge: time.Minute})
+	exporter.opened = time.Now()
+
+	if exporter.expired(exporter.opened.Add(time.Second)) {
+		t.Error("Expected: file is not expired")
+	}
+	if !exporter.expired(exporter.opened.Add(time.Minute)) {
+		t.Error("Expected: file is expired")
+	}
+}
+
+func TestFileSinkStartStop(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "metrics.ndjson")
+	mhost := NewHost(&Config{Port: 1000000, File: &FileConfig{Path: path, Interval: time.Hour}}, testlog.NewLogbook())
+	mhost.Start()
+	mhost.Stop()
+
+	// the last snapshot is written on stop
+	data, err := ioutil.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), `"name":"uptime"`) {
+		t.Errorf("Expected: snapshot written on stop, but got %v %s", err, string(data))
+	}
+}
@@ -6,6 +6,7 @@ type gauge struct {
 	name  string
 	help  string
 	value int64
+	metadata
 }
 
 func (metric *gauge) Name() string {
@@ -0,0 +1,253 @@
+package metricer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GraphiteConfig represents configuration of Graphite exporter
+type GraphiteConfig struct {
+	// Address of Carbon endpoint, host:port
+	Address string `json:"address,omitempty" yaml:"address"`
+
+	// Prefix added to every metric path
+	Prefix string `json:"prefix,omitempty" yaml:"prefix"`
+
+	// Interval between pushes
+	Interval time.Duration `json:"interval,omitempty" yaml:"interval"`
+
+	// Pickle enables pickle protocol instead of plaintext one
+	Pickle bool `json:"pickle,omitempty" yaml:"pickle"`
+
+	// Buffer is max number of data points kept in memory while Carbon is not available
+	Buffer int `json:"buffer,omitempty" yaml:"buffer"`
+
+	// Timeout for connect and write operations
+	Timeout time.Duration `json:"timeout,omitempty" yaml:"timeout"`
+}
+
+// Validate checks Graphite config structure
+func (config *GraphiteConfig) Validate() error {
+	if config.Address == "" {
+		config.Address = defaultGraphiteAddress
+		if config.Pickle {
+			config.Address = defaultGraphitePickleAddress
+		}
+	}
+	if config.Interval <= 0 {
+		config.Interval = defaultPushInterval
+	}
+	if config.Buffer <= 0 {
+		config.Buffer = defaultGraphiteBuffer
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultPushTimeout
+	}
+	return nil
+}
+
+type graphitePoint struct {
+	path      string
+	value     float64
+	timestamp int64
+}
+
+type graphite struct {
+	config  GraphiteConfig
+	conn    net.Conn
+	buffer  []graphitePoint // data points which are not sent yet
+	backoff time.Duration
+	next    time.Time // time of the next connection attempt
+}
+
+func newGraphite(config GraphiteConfig) *graphite {
+	return &graphite{config: config}
+}
+
+func (exporter *graphite) name() string {
+	return "graphite"
+}
+
+func (exporter *graphite) interval() time.Duration {
+	return exporter.config.Interval
+}
+
+func (exporter *graphite) close() error {
+	if exporter.conn == nil {
+		return nil
+	}
+	err := exporter.conn.Close()
+	exporter.conn = nil
+	return err
+}
+
+func (exporter *graphite) push(snap *snapshot) error {
+	exporter.enqueue(snap)
+
+	if time.Now().Before(exporter.next) {
+		return nil
+	}
+
+	if err := exporter.send(); err != nil {
+		exporter.close()
+
+		// exponential backoff for reconnection attempts
+		exporter.backoff *= 2
+		if exporter.backoff < time.Second {
+			exporter.backoff = time.Second
+		}
+		if exporter.backoff > maxPushBackoff {
+			exporter.backoff = maxPushBackoff
+		}
+		exporter.next = time.Now().Add(exporter.backoff)
+		return err
+	}
+
+	exporter.backoff = 0
+	return nil
+}
+
+// enqueue adds data points of the snapshot to the buffer, the oldest points are dropped on overflow
+func (exporter *graphite) enqueue(snap *snapshot) {
+	timestamp := snap.time.Unix()
+
+	add := func(name string, labels []labelPair, value float64) {
+		exporter.buffer = append(exporter.buffer, graphitePoint{
+			path:      exporter.path(snap.labels, name, labels),
+			value:     value,
+			timestamp: timestamp,
+		})
+	}
+
+	for _, f := range snap.families {
+		for _, s := range f.series {
+			if s.hist != nil {
+				add(f.name+".count", s.labels, float64(s.hist.count))
+				add(f.name+".sum", s.labels, s.hist.sum)
+				continue
+			}
+			add(f.name, s.labels, s.value)
+		}
+	}
+
+	if overflow := len(exporter.buffer) - exporter.config.Buffer; overflow > 0 {
+		exporter.buffer = append(exporter.buffer[:0], exporter.buffer[overflow:]...)
+	}
+}
+
+// send writes all buffered data points to Carbon
+func (exporter *graphite) send() error {
+	if exporter.conn == nil {
+		conn, err := net.DialTimeout("tcp", exporter.config.Address, exporter.config.Timeout)
+		if err != nil {
+			return err
+		}
+		exporter.conn = conn
+	}
+
+	exporter.conn.SetWriteDeadline(time.Now().Add(exporter.config.Timeout))
+	w := bufio.NewWriter(exporter.conn)
+
+	for sent := 0; sent < len(exporter.buffer); {
+		batch := exporter.buffer[sent:]
+		if len(batch) > graphiteBatchSize {
+			batch = batch[:graphiteBatchSize]
+		}
+
+		if exporter.config.Pickle {
+			w.Write(graphitePickle(batch))
+		} else {
+			for _, v := range batch {
+				w.WriteString(v.path)
+				w.WriteByte(' ')
+				w.WriteString(formatValue(v.value))
+				w.WriteByte(' ')
+				w.WriteString(strconv.FormatInt(v.timestamp, 10))
+				w.WriteByte('\n')
+			}
+		}
+
+		if err := w.Flush(); err != nil {
+			exporter.buffer = append(exporter.buffer[:0], exporter.buffer[sent:]...)
+			return err
+		}
+		sent += len(batch)
+	}
+
+	exporter.buffer = exporter.buffer[:0]
+	return nil
+}
+
+var graphiteEscaper = strings.NewReplacer(" ", "_", ".", "_", "/", "_", "\n", "_", "\t", "_")
+
+// path builds metric path from prefix, values of host labels, metric name and values of series labels
+func (exporter *graphite) path(hostlabels []labelPair, name string, labels []labelPair) string {
+	parts := make([]string, 0, len(hostlabels)+len(labels)+2)
+	if exporter.config.Prefix != "" {
+		parts = append(parts, exporter.config.Prefix)
+	}
+
+	value := func(v string) string {
+		if v == "" {
+			return "_"
+		}
+		return graphiteEscaper.Replace(v)
+	}
+
+	for _, v := range hostlabels {
+		parts = append(parts, value(v.value))
+	}
+	// dots in metric name are kept as path separators
+	parts = append(parts, strings.Replace(name, " ", "_", -1))
+	for _, v := range labels {
+		parts = append(parts, value(v.value))
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// graphitePickle encodes data points as list of (path, (timestamp, value)) tuples
+// in pickle protocol 2, prefixed with the message length
+func graphitePickle(points []graphitePoint) []byte {
+	var b bytes.Buffer
+	b.Write([]byte{0, 0, 0, 0}) // length placeholder
+
+	b.Write([]byte{0x80, 2}) // PROTO 2
+	b.WriteByte(']')         // EMPTY_LIST
+	b.WriteByte('(')         // MARK
+
+	var buf [8]byte
+	for _, v := range points {
+		// path as BINUNICODE
+		b.WriteByte('X')
+		binary.LittleEndian.PutUint32(buf[:4], uint32(len(v.path)))
+		b.Write(buf[:4])
+		b.WriteString(v.path)
+
+		// timestamp as LONG1
+		b.Write([]byte{0x8a, 8})
+		binary.LittleEndian.PutUint64(buf[:], uint64(v.timestamp))
+		b.Write(buf[:])
+
+		// value as BINFLOAT
+		b.WriteByte('G')
+		binary.BigEndian.PutUint64(buf[:], math.Float64bits(v.value))
+		b.Write(buf[:])
+
+		b.WriteByte(0x86) // TUPLE2 (timestamp, value)
+		b.WriteByte(0x86) // TUPLE2 (path, (timestamp, value))
+	}
+
+	b.WriteByte('e') // APPENDS
+	b.WriteByte('.') // STOP
+
+	data := b.Bytes()
+	binary.BigEndian.PutUint32(data[:4], uint32(len(data)-4))
+	return data
+}
@@ -0,0 +1,190 @@
+package metricer
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"go.melnyk.org/mlog/testlog"
+)
+
+const goos = runtime.GOOS
+
+func listenGraphite(t *testing.T, address string) (net.Listener, chan []byte) {
+	ln, err := net.Listen("tcp", address)
+	if err != nil {
+		t.Fatalf("Expected: TCP listener, but got %s", err.Error())
+	}
+
+	received := make(chan []byte, 16)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 65536)
+				for {
+					n, err := conn.Read(buf)
+					if n > 0 {
+						data := make([]byte, n)
+						copy(data, buf[:n])
+						received <- data
+					}
+					if err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	return ln, received
+}
+
+func readGraphite(t *testing.T, received chan []byte) string {
+	select {
+	case data := <-received:
+		return string(data)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected: data from exporter, but got timeout")
+	}
+	return ""
+}
+
+func TestGraphiteConfigDefaults(t *testing.T) {
+	cfg := &GraphiteConfig{Pickle: true}
+	cfg.Validate()
+	if cfg.Address != defaultGraphitePickleAddress || cfg.Buffer != defaultGraphiteBuffer || cfg.Interval != defaultPushInterval {
+		t.Errorf("Expected: default Graphite config values, but got %v", cfg)
+	}
+}
+
+func TestGraphitePath(t *testing.T) {
+	exporter := newGraphite(GraphiteConfig{Prefix: "app"})
+	hostlabels := []labelPair{{name: "_os", value: "linux"}, {name: "env", value: "prod.eu"}}
+	labels := []labelPair{{name: "check", value: "db"}}
+
+	if path := exporter.path(hostlabels, "requests", labels); path != "app.linux.prod_eu.requests.db" {
+		t.Errorf("Expected: app.linux.prod_eu.requests.db, but got %s", path)
+	}
+}
+
+func TestGraphitePushPlaintext(t *testing.T) {
+	ln, received := listenGraphite(t, "127.0.0.1:0")
+	defer ln.Close()
+
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewCounter("counter", "counter help").Inc(42)
+
+	cfg := GraphiteConfig{Address: ln.Addr().String(), Prefix: "app"}
+	cfg.Validate()
+	exporter := newGraphite(cfg)
+	defer exporter.close()
+
+	snap := mhost.collect()
+	if err := exporter.push(snap); err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+
+	data := readGraphite(t, received)
+	expected := "app." + goos + ".counter 42 " + formatValue(float64(snap.time.Unix())) + "\n"
+	if !strings.Contains(data, expected) {
+		t.Errorf("Expected: %q in data, but got %s", expected, data)
+	}
+}
+
+func TestGraphitePushPickle(t *testing.T) {
+	ln, received := listenGraphite(t, "127.0.0.1:0")
+	defer ln.Close()
+
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewCounter("counter", "counter help").Inc(42)
+
+	cfg := GraphiteConfig{Address: ln.Addr().String(), Pickle: true}
+	cfg.Validate()
+	exporter := newGraphite(cfg)
+	defer exporter.close()
+
+	if err := exporter.push(mhost.collect()); err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+
+	r := bufio.NewReader(strings.NewReader(readGraphite(t, received)))
+	var size uint32
+	binary.Read(r, binary.BigEndian, &size)
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatalf("Expected: complete pickle message, but got %s", err.Error())
+	}
+	if payload[0] != 0x80 || payload[len(payload)-1] != '.' {
+		t.Errorf("Expected: pickle protocol message, but got %v", payload)
+	}
+	if !strings.Contains(string(payload), goos+".counter") {
+		t.Errorf("Expected: counter path in message, but got %q", payload)
+	}
+}
+
+func TestGraphiteBufferWhileDown(t *testing.T) {
+	// reserve free port and release it
+	ln, _ := listenGraphite(t, "127.0.0.1:0")
+	address := ln.Addr().String()
+	ln.Close()
+
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewCounter("counter", "counter help").Inc(42)
+
+	cfg := GraphiteConfig{Address: address, Timeout: time.Second}
+	cfg.Validate()
+	exporter := newGraphite(cfg)
+	defer exporter.close()
+
+	if err := exporter.push(mhost.collect()); err == nil {
+		t.Fatal("Expected: error while Carbon is down, but got nil")
+	}
+	buffered := len(exporter.buffer)
+	if buffered == 0 {
+		t.Fatal("Expected: buffered data points, but got none")
+	}
+
+	// push within backoff period only buffers data
+	if err := exporter.push(mhost.collect()); err != nil {
+		t.Fatalf("Expected: no errors within backoff, but got %s", err.Error())
+	}
+	if len(exporter.buffer) != 2*buffered {
+		t.Errorf("Expected: %d buffered data points, but got %d", 2*buffered, len(exporter.buffer))
+	}
+
+	ln, received := listenGraphite(t, address)
+	defer ln.Close()
+
+	exporter.next = time.Time{}
+	if err := exporter.push(mhost.collect()); err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+	if len(exporter.buffer) != 0 {
+		t.Errorf("Expected: empty buffer, but got %d data points", len(exporter.buffer))
+	}
+
+	data := ""
+	for strings.Count(data, "\n") < 3*buffered {
+		data += readGraphite(t, received)
+	}
+}
+
+func TestGraphiteBufferBounded(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	exporter := newGraphite(GraphiteConfig{Buffer: 5})
+	exporter.enqueue(mhost.collect())
+	exporter.enqueue(mhost.collect())
+	if len(exporter.buffer) != 5 {
+		t.Errorf("Expected: 5 buffered data points, but got %d", len(exporter.buffer))
+	}
+}
@@ -0,0 +1,210 @@
+package metricer
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go.melnyk.org/mlog"
+)
+
+// Statuses of grpc.health.v1 protocol
+const (
+	grpcUnknown        = "UNKNOWN"
+	grpcServing        = "SERVING"
+	grpcNotServing     = "NOT_SERVING"
+	grpcServiceUnknown = "SERVICE_UNKNOWN"
+)
+
+const (
+	grpcWatchInterval   = time.Second
+	grpcWatchTimeout    = 30 * time.Second
+	grpcWatchMaxTimeout = 5 * time.Minute
+)
+
+// grpcStatus returns status of the service in terms of grpc.health.v1 protocol.
+// Service is matched by health check name or group, empty service means overall status of the host.
+// Cached statuses of the last checks are used instead of running health checks when cached is set.
+func (h *host) grpcStatus(service string, cached bool) string {
+	h.mu.RLock()
+	healthchecks := make([]*health, len(h.healthchecks))
+	copy(healthchecks, h.healthchecks)
+	h.mu.RUnlock()
+
+	match := func(metric *health) bool {
+		return service == "" || metric.Name() == service || metric.inGroup(service)
+	}
+
+	if service != "" {
+		found := false
+		for _, v := range healthchecks {
+			if match(v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return grpcServiceUnknown
+		}
+	}
+
+	if h.isDraining() {
+		return grpcNotServing
+	}
+
+	var statuses []healthStatus
+	if cached {
+		for _, v := range healthchecks {
+			if match(v) {
+				statuses = append(statuses, v.status())
+			}
+		}
+	} else {
+		for _, v := range checkMatching(healthchecks, match) {
+			statuses = append(statuses, v.status)
+		}
+	}
+
+	status := grpcServing
+	for _, v := range statuses {
+		switch v {
+		case healthOk:
+		case healthUnknown:
+			status = grpcUnknown
+		default:
+			return grpcNotServing
+		}
+	}
+
+	return status
+}
+
+// refreshHealth runs all health checks at most once per watch interval, so the run is shared by all watchers
+func (h *host) refreshHealth() {
+	h.refreshmu.Lock()
+	defer h.refreshmu.Unlock()
+
+	if time.Since(h.refreshed) < grpcWatchInterval {
+		return
+	}
+
+	h.mu.RLock()
+	healthchecks := make([]*health, len(h.healthchecks))
+	copy(healthchecks, h.healthchecks)
+	h.mu.RUnlock()
+
+	checkMatching(healthchecks, func(*health) bool {
+		return true
+	})
+	h.refreshed = time.Now()
+}
+
+func (h *host) grpcHealthCheck(w http.ResponseWriter, r *http.Request) {
+	if !h.enterHandler(w, r) {
+		return
+	}
+	defer h.wg.Done()
+
+	// only GET method is allowed
+	if r.Method != http.MethodGet {
+		h.handlerError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	service := r.URL.Query().Get("service")
+
+	h.log.Event(mlog.Verbose, func(e mlog.Event) {
+		e.String("msg", "gRPC health check request")
+		e.String("remote", r.RemoteAddr)
+		e.String("service", service)
+	})
+
+	status := h.grpcStatus(service, false)
+	if status == grpcServiceUnknown {
+		h.handlerError(w, r, http.StatusNotFound, "Service not found")
+		return
+	}
+
+	h.grpcResponse(w, status)
+}
+
+// grpcHealthWatch waits until status of the service differs from the last known status
+// provided by the client, or until timeout
+func (h *host) grpcHealthWatch(w http.ResponseWriter, r *http.Request) {
+	if !h.enterHandler(w, r) {
+		return
+	}
+	defer h.wg.Done()
+
+	// only GET method is allowed
+	if r.Method != http.MethodGet {
+		h.handlerError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	service := query.Get("service")
+	last := query.Get("last")
+
+	timeout := grpcWatchTimeout
+	if v := query.Get("timeout"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			h.handlerError(w, r, http.StatusBadRequest, "Incorrect timeout value")
+			return
+		}
+		timeout = d
+	}
+	if timeout > grpcWatchMaxTimeout {
+		timeout = grpcWatchMaxTimeout
+	}
+
+	h.log.Event(mlog.Verbose, func(e mlog.Event) {
+		e.String("msg", "gRPC health watch request")
+		e.String("remote", r.RemoteAddr)
+		e.String("service", service)
+		e.String("last", last)
+	})
+
+	status := h.grpcStatus(service, false)
+	if last == "" || status != last {
+		h.grpcResponse(w, status)
+		return
+	}
+
+	ticker := time.NewTicker(grpcWatchInterval)
+	defer ticker.Stop()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+watch:
+	for {
+		select {
+		case <-ticker.C:
+			h.refreshHealth()
+			if status = h.grpcStatus(service, true); status != last {
+				break watch
+			}
+		case <-timer.C:
+			break watch
+		case <-h.done:
+			status = grpcNotServing
+			break watch
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	h.grpcResponse(w, status)
+}
+
+func (h *host) grpcResponse(w http.ResponseWriter, status string) {
+	data := struct {
+		Status string `json:"status"`
+	}{
+		Status: status,
+	}
+
+	w.Header().Set("Content-Type", contenttypeJSON)
+	json.NewEncoder(w).Encode(data)
+}
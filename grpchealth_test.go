@@ -0,0 +1,175 @@
+package metricer
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.melnyk.org/mlog/testlog"
+)
+
+func grpcStatusFromResponse(t *testing.T, w *httptest.ResponseRecorder) string {
+	data := struct {
+		Status string `json:"status"`
+	}{}
+	if err := json.NewDecoder(w.Result().Body).Decode(&data); err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+	return data.Status
+}
+
+func TestGrpcHealthCheck(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewHealthCheck("db", "db help", func() error {
+		return errors.New("failed")
+	}, HealthGroup("storage"))
+	mhost.NewHealthCheck("api", "api help", func() error {
+		return nil
+	})
+
+	tests := []struct {
+		method string
+		url    string
+		code   int
+		status string
+	}{
+		{"GET", "http://test/health/grpc", http.StatusOK, grpcNotServing},
+		{"GET", "http://test/health/grpc?service=api", http.StatusOK, grpcServing},
+		{"GET", "http://test/health/grpc?service=db", http.StatusOK, grpcNotServing},
+		{"GET", "http://test/health/grpc?service=storage", http.StatusOK, grpcNotServing},
+		{"GET", "http://test/health/grpc?service=unknown", http.StatusNotFound, ""},
+		{"POST", "http://test/health/grpc", http.StatusMethodNotAllowed, ""},
+	}
+
+	for i, v := range tests {
+		req := httptest.NewRequest(v.method, v.url, nil)
+		w := httptest.NewRecorder()
+		mhost.grpcHealthCheck(w, req)
+
+		if code := w.Result().StatusCode; code != v.code {
+			t.Errorf("Expected (%d): %d, but got %d", i, v.code, code)
+		}
+		if v.status == "" {
+			continue
+		}
+		if status := grpcStatusFromResponse(t, w); status != v.status {
+			t.Errorf("Expected (%d): %s, but got %s", i, v.status, status)
+		}
+	}
+}
+
+func TestGrpcHealthCheckDraining(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewHealthCheck("api", "api help", nil)
+	mhost.SetDraining(true)
+
+	if status := mhost.grpcStatus("", false); status != grpcNotServing {
+		t.Errorf("Expected: %s, but got %s", grpcNotServing, status)
+	}
+	if status := mhost.grpcStatus("api", false); status != grpcNotServing {
+		t.Errorf("Expected: %s, but got %s", grpcNotServing, status)
+	}
+}
+
+func TestGrpcHealthWatch(t *testing.T) {
+	var failed int32
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewHealthCheck("api", "api help", func() error {
+		if atomic.LoadInt32(&failed) != 0 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	// status is returned immediately without last known status
+	req := httptest.NewRequest("GET", "http://test/health/grpc/watch?service=api", nil)
+	w := httptest.NewRecorder()
+	mhost.grpcHealthWatch(w, req)
+	if status := grpcStatusFromResponse(t, w); status != grpcServing {
+		t.Fatalf("Expected: %s, but got %s", grpcServing, status)
+	}
+
+	// status is returned after change
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		atomic.StoreInt32(&failed, 1)
+	}()
+	req = httptest.NewRequest("GET", "http://test/health/grpc/watch?service=api&last=SERVING", nil)
+	w = httptest.NewRecorder()
+	mhost.grpcHealthWatch(w, req)
+	if status := grpcStatusFromResponse(t, w); status != grpcNotServing {
+		t.Errorf("Expected: %s, but got %s", grpcNotServing, status)
+	}
+
+	// status is returned after timeout
+	req = httptest.NewRequest("GET", "http://test/health/grpc/watch?service=api&last=NOT_SERVING&timeout=10ms", nil)
+	w = httptest.NewRecorder()
+	mhost.grpcHealthWatch(w, req)
+	if status := grpcStatusFromResponse(t, w); status != grpcNotServing {
+		t.Errorf("Expected: %s, but got %s", grpcNotServing, status)
+	}
+
+	// unknown service is reported by status
+	req = httptest.NewRequest("GET", "http://test/health/grpc/watch?service=unknown", nil)
+	w = httptest.NewRecorder()
+	mhost.grpcHealthWatch(w, req)
+	if status := grpcStatusFromResponse(t, w); status != grpcServiceUnknown {
+		t.Errorf("Expected: %s, but got %s", grpcServiceUnknown, status)
+	}
+
+	req = httptest.NewRequest("GET", "http://test/health/grpc/watch?timeout=foo", nil)
+	w = httptest.NewRecorder()
+	mhost.grpcHealthWatch(w, req)
+	if code := w.Result().StatusCode; code != http.StatusBadRequest {
+		t.Errorf("Expected: %d, but got %d", http.StatusBadRequest, code)
+	}
+}
+
+func TestGrpcHealthWatchStop(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewHealthCheck("api", "api help", nil)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		mhost.Stop()
+	}()
+
+	req := httptest.NewRequest("GET", "http://test/health/grpc/watch?last=SERVING", nil)
+	w := httptest.NewRecorder()
+	mhost.grpcHealthWatch(w, req)
+	if status := grpcStatusFromResponse(t, w); status != grpcNotServing {
+		t.Errorf("Expected: %s, but got %s", grpcNotServing, status)
+	}
+}
+
+func TestGrpcHealthWatchSharedRun(t *testing.T) {
+	var calls int32
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewHealthCheck("api", "api help", func() error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+
+	// cached statuses do not run health checks
+	if status := mhost.grpcStatus("api", true); status != grpcUnknown {
+		t.Errorf("Expected: %s, but got %s", grpcUnknown, status)
+	}
+	if n := atomic.LoadInt32(&calls); n != 0 {
+		t.Errorf("Expected: no health check runs, but got %d", n)
+	}
+
+	// watchers share one run per interval
+	for i := 0; i < 10; i++ {
+		mhost.refreshHealth()
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Expected: 1 health check run, but got %d", n)
+	}
+	if status := mhost.grpcStatus("api", true); status != grpcServing {
+		t.Errorf("Expected: %s, but got %s", grpcServing, status)
+	}
+}
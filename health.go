@@ -1,9 +1,129 @@
 package metricer
 
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HealthOption provides optional settings for health checks
+type HealthOption func(*health)
+
+// FailureThreshold sets number of consecutive failures before healthy check is reported as failed
+func FailureThreshold(n uint) HealthOption {
+	return func(metric *health) {
+		metric.failureThreshold = n
+	}
+}
+
+// SuccessThreshold sets number of consecutive successes before failed check is reported as healthy again
+func SuccessThreshold(n uint) HealthOption {
+	return func(metric *health) {
+		metric.successThreshold = n
+	}
+}
+
+// ThresholdJitter adds random number (from 0 up to n) of extra consecutive failures to the failure threshold,
+// so instances sharing the same dependency do not fail at the same moment
+func ThresholdJitter(n uint) HealthOption {
+	return func(metric *health) {
+		metric.jitter = n
+	}
+}
+
+// HealthGroup adds health check to the named groups, which can be checked separately
+func HealthGroup(groups ...string) HealthOption {
+	return func(metric *health) {
+		metric.groups = append(metric.groups, groups...)
+	}
+}
+
+// DependsOn declares health checks which must pass before this check is called,
+// the check is reported as skipped if any of its dependencies did not pass
+func DependsOn(names ...string) HealthOption {
+	return func(metric *health) {
+		metric.dependencies = append(metric.dependencies, names...)
+	}
+}
+
+type healthStatus int32
+
+const (
+	healthUnknown healthStatus = iota
+	healthOk
+	healthFailed
+	healthSkipped
+	healthStatusCount
+)
+
+func (status healthStatus) String() string {
+	switch status {
+	case healthOk:
+		return "ok"
+	case healthFailed:
+		return "failed"
+	case healthSkipped:
+		return "skipped"
+	default:
+		return "unknown"
+	}
+}
+
+// value returns status representation for the status gauge
+func (status healthStatus) value() float64 {
+	switch status {
+	case healthOk:
+		return 1
+	case healthFailed:
+		return 0
+	default:
+		return -1
+	}
+}
+
 type health struct {
-	name    string
-	help    string
-	checker HealthcheckFunc
+	state       int32 // healthStatus
+	transitions [healthStatusCount][healthStatusCount]uint64
+
+	name     string
+	help     string
+	checker  HealthcheckFunc
+	duration *histogram
+
+	failureThreshold uint
+	successThreshold uint
+	jitter           uint
+
+	groups       []string
+	dependencies []string
+
+	mu        sync.Mutex // protects checker and fields below
+	failures  uint       // consecutive failures
+	successes uint       // consecutive successes
+	limit     uint       // failure threshold for the current streak, including jitter
+	lasterr   error
+
+	// onTransition is called each time when status of the check is changed
+	onTransition func(metric *health, from, to healthStatus, err error)
+	// onRemove is called when the check is removed
+	onRemove func(metric *health)
+}
+
+func newHealth(name string, help string, checker HealthcheckFunc, options ...HealthOption) *health {
+	metric := &health{
+		name:     name,
+		help:     help,
+		checker:  checker,
+		duration: newHistogram(name, help, DefaultBuckets),
+	}
+
+	for _, option := range options {
+		option(metric)
+	}
+
+	return metric
 }
 
 func (metric *health) Name() string {
@@ -14,16 +134,208 @@ func (metric *health) Help() string {
 	return metric.help
 }
 
+// Check calls health checker and returns error if check is considered as failed.
+// Healthy check is reported as failed only after number of consecutive failures
+// defined by failure threshold, and failed check is reported as healthy again only
+// after number of consecutive successes defined by success threshold.
 func (metric *health) Check() (err error) {
+	started := time.Now()
+
 	defer func() {
 		if r := recover(); r != nil {
 			err = errHealthCheckerPanic
 		}
+		err = metric.record(err, time.Since(started))
 	}()
 
-	if metric.checker != nil {
-		err = metric.checker()
+	metric.mu.Lock()
+	checker := metric.checker
+	metric.mu.Unlock()
+
+	if checker != nil {
+		err = checker()
 	}
 
 	return
 }
+
+// Remove withdraws the check from the host
+func (metric *health) Remove() {
+	if metric.onRemove != nil {
+		metric.onRemove(metric)
+	}
+}
+
+// Replace sets new health checker for the check
+func (metric *health) Replace(checker HealthcheckFunc) {
+	metric.mu.Lock()
+	metric.checker = checker
+	metric.mu.Unlock()
+}
+
+func (metric *health) inGroup(group string) bool {
+	for _, v := range metric.groups {
+		if v == group {
+			return true
+		}
+	}
+	return false
+}
+
+// skip marks the check as skipped, without calling health checker
+func (metric *health) skip(reason error) {
+	from := healthStatus(atomic.SwapInt32(&metric.state, int32(healthSkipped)))
+	if from == healthSkipped {
+		return
+	}
+
+	atomic.AddUint64(&metric.transitions[from][healthSkipped], 1)
+
+	if metric.onTransition != nil {
+		metric.onTransition(metric, from, healthSkipped, reason)
+	}
+}
+
+func (metric *health) status() healthStatus {
+	return healthStatus(atomic.LoadInt32(&metric.state))
+}
+
+func (metric *health) transitionsCount(from, to healthStatus) uint64 {
+	return atomic.LoadUint64(&metric.transitions[from][to])
+}
+
+// record updates state of the check with result of the last call and returns error to report
+func (metric *health) record(err error, elapsed time.Duration) error {
+	if metric.duration != nil {
+		metric.duration.Observe(elapsed.Seconds())
+	}
+
+	metric.mu.Lock()
+
+	from := metric.status()
+	to := from
+
+	if err != nil {
+		if metric.failures == 0 {
+			metric.limit = metric.failureThreshold
+			if metric.jitter > 0 {
+				metric.limit += uint(rand.Int63n(int64(metric.jitter) + 1))
+			}
+		}
+		metric.failures++
+		metric.successes = 0
+		metric.lasterr = err
+
+		// there is no healthy state to protect before the first successful check
+		if from == healthUnknown || from == healthSkipped || metric.failures >= metric.limit {
+			to = healthFailed
+		}
+	} else {
+		metric.successes++
+		metric.failures = 0
+
+		if from == healthUnknown || from == healthSkipped || metric.successes >= metric.successThreshold {
+			to = healthOk
+		}
+	}
+
+	var report error
+	if to == healthFailed {
+		report = metric.lasterr
+	}
+
+	if from != to {
+		atomic.StoreInt32(&metric.state, int32(to))
+		atomic.AddUint64(&metric.transitions[from][to], 1)
+	}
+
+	metric.mu.Unlock()
+
+	if from != to && metric.onTransition != nil {
+		metric.onTransition(metric, from, to, report)
+	}
+
+	return report
+}
+
+type healthResult struct {
+	metric *health
+	status healthStatus
+	err    error
+}
+
+// checkAll calls health checks of the group (or all checks if group is empty)
+func checkAll(healthchecks []*health, group string) []healthResult {
+	return checkMatching(healthchecks, func(metric *health) bool {
+		return group == "" || metric.inGroup(group)
+	})
+}
+
+// checkMatching calls health checks selected by match function,
+// dependencies of each check are called before the check itself
+func checkMatching(healthchecks []*health, match func(*health) bool) []healthResult {
+	byname := make(map[string]*health, len(healthchecks))
+	for _, v := range healthchecks {
+		byname[v.Name()] = v
+	}
+
+	results := make(map[*health]*healthResult, len(healthchecks))
+	visiting := make(map[*health]bool)
+
+	var visit func(metric *health) *healthResult
+	visit = func(metric *health) *healthResult {
+		if result, ok := results[metric]; ok {
+			return result
+		}
+
+		visiting[metric] = true
+		result := &healthResult{metric: metric}
+
+		for _, name := range metric.dependencies {
+			dependency, ok := byname[name]
+			switch {
+			case !ok:
+				result.status, result.err = healthFailed, errHealthDependencyMissing
+			case visiting[dependency]:
+				result.status, result.err = healthFailed, errHealthDependencyCycle
+			default:
+				if visit(dependency).status != healthOk {
+					result.status, result.err = healthSkipped, fmt.Errorf("Dependency %s did not pass", name)
+				}
+			}
+			if result.err != nil {
+				break
+			}
+		}
+
+		switch result.status {
+		case healthSkipped:
+			metric.skip(result.err)
+		case healthFailed:
+			// broken dependencies are reported in the same way as failures of the check
+			result.err = metric.record(result.err, 0)
+		default:
+			result.err = metric.Check()
+		}
+
+		if result.status != healthSkipped {
+			result.status = healthOk
+			if result.err != nil {
+				result.status = healthFailed
+			}
+		}
+
+		delete(visiting, metric)
+		results[metric] = result
+		return result
+	}
+
+	list := make([]healthResult, 0, len(healthchecks))
+	for _, v := range healthchecks {
+		if match(v) {
+			list = append(list, *visit(v))
+		}
+	}
+
+	return list
+}
@@ -70,3 +70,193 @@ func TestHealthCheckWithPanic(t *testing.T) {
 		t.Fatalf("Expected: error errHealthCheckerPanic expected but got error %s", err.Error())
 	}
 }
+
+// TestHealthCheckTransitions validates status tracking for health checker
+func TestHealthCheckTransitions(t *testing.T) {
+	var failed error
+	calls := 0
+	metric := newHealth("health-name", "help", func() error {
+		return failed
+	})
+	metric.onTransition = func(m *health, from, to healthStatus, err error) {
+		calls++
+	}
+
+	if val := metric.status(); val != healthUnknown {
+		t.Errorf("Expected: unknown status but got %s", val)
+	}
+
+	metric.Check()
+	metric.Check()
+	if val := metric.status(); val != healthOk {
+		t.Errorf("Expected: ok status but got %s", val)
+	}
+
+	failed = errors.New("failed")
+	metric.Check()
+	if val := metric.status(); val != healthFailed {
+		t.Errorf("Expected: failed status but got %s", val)
+	}
+
+	if calls != 2 {
+		t.Errorf("Expected: 2 transitions but got %d", calls)
+	}
+	if val := metric.transitionsCount(healthOk, healthFailed); val != 1 {
+		t.Errorf("Expected: 1 transition from ok to failed but got %d", val)
+	}
+	if val := metric.duration.Count(); val != 3 {
+		t.Errorf("Expected: 3 duration observations but got %d", val)
+	}
+}
+
+// TestHealthCheckFailureThreshold validates flap damping for failures of health checker
+func TestHealthCheckFailureThreshold(t *testing.T) {
+	var failed error
+	metric := newHealth("health-name", "help", func() error {
+		return failed
+	}, FailureThreshold(3))
+
+	if err := metric.Check(); err != nil {
+		t.Fatalf("Expected: no errors but got %s", err.Error())
+	}
+
+	failed = errors.New("failed")
+	for i := 0; i < 2; i++ {
+		if err := metric.Check(); err != nil {
+			t.Fatalf("Expected (%d): no errors before threshold but got %s", i, err.Error())
+		}
+	}
+	if err := metric.Check(); err != failed {
+		t.Fatalf("Expected: error failed after threshold but got %v", err)
+	}
+	if val := metric.status(); val != healthFailed {
+		t.Errorf("Expected: failed status but got %s", val)
+	}
+}
+
+// TestHealthCheckSuccessThreshold validates flap damping for recovery of health checker
+func TestHealthCheckSuccessThreshold(t *testing.T) {
+	failed := errors.New("failed")
+	metric := newHealth("health-name", "help", func() error {
+		return failed
+	}, SuccessThreshold(2))
+
+	if err := metric.Check(); err == nil {
+		t.Fatal("Expected: error for the first failed call but got no error")
+	}
+
+	failed = nil
+	if err := metric.Check(); err == nil || err.Error() != "failed" {
+		t.Fatalf("Expected: last error before threshold but got %v", err)
+	}
+	if err := metric.Check(); err != nil {
+		t.Fatalf("Expected: no errors after threshold but got %s", err.Error())
+	}
+	if val := metric.status(); val != healthOk {
+		t.Errorf("Expected: ok status but got %s", val)
+	}
+}
+
+// TestHealthCheckThresholdJitter validates failure threshold with jitter
+func TestHealthCheckThresholdJitter(t *testing.T) {
+	metric := newHealth("health-name", "help", func() error {
+		return nil
+	}, FailureThreshold(2), ThresholdJitter(3))
+	metric.Check()
+
+	metric.checker = func() error {
+		return errors.New("failed")
+	}
+
+	calls := 0
+	for metric.Check() == nil {
+		calls++
+		if calls > 5 {
+			t.Fatal("Expected: failure reported within threshold and jitter")
+		}
+	}
+	if calls < 1 {
+		t.Errorf("Expected: at least 1 suppressed failure but got %d", calls)
+	}
+}
+
+// TestHealthCheckAllDependencies validates skipping checks with failed dependencies
+func TestHealthCheckAllDependencies(t *testing.T) {
+	called := false
+	healthchecks := []*health{
+		newHealth("schema", "help", func() error {
+			called = true
+			return nil
+		}, DependsOn("db")),
+		newHealth("db", "help", func() error {
+			return errors.New("failed")
+		}),
+		newHealth("cache", "help", func() error {
+			return nil
+		}),
+	}
+
+	results := checkAll(healthchecks, "")
+	expected := []healthStatus{healthSkipped, healthFailed, healthOk}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected: %d results but got %d", len(expected), len(results))
+	}
+	for i, v := range expected {
+		if results[i].status != v {
+			t.Errorf("Expected (%d): %s but got %s", i, v, results[i].status)
+		}
+	}
+	if called {
+		t.Error("Expected: checker with failed dependency is not called")
+	}
+	if val := healthchecks[0].status(); val != healthSkipped {
+		t.Errorf("Expected: skipped status but got %s", val)
+	}
+}
+
+// TestHealthCheckAllBrokenDependencies validates missing and cyclic dependencies
+func TestHealthCheckAllBrokenDependencies(t *testing.T) {
+	healthchecks := []*health{
+		newHealth("missing", "help", nil, DependsOn("unknown")),
+		newHealth("a", "help", nil, DependsOn("b")),
+		newHealth("b", "help", nil, DependsOn("a")),
+	}
+
+	results := checkAll(healthchecks, "")
+	if results[0].err != errHealthDependencyMissing {
+		t.Errorf("Expected: missing dependency error but got %v", results[0].err)
+	}
+	if results[0].status != healthFailed {
+		t.Errorf("Expected: failed status but got %s", results[0].status)
+	}
+	if results[1].status != healthSkipped {
+		t.Errorf("Expected: skipped status but got %s", results[1].status)
+	}
+	if results[2].err != errHealthDependencyCycle {
+		t.Errorf("Expected: dependency cycle error but got %v", results[2].err)
+	}
+}
+
+// TestHealthCheckAllGroup validates calling checks of the group only
+func TestHealthCheckAllGroup(t *testing.T) {
+	calls := make(map[string]int)
+	checker := func(name string) HealthcheckFunc {
+		return func() error {
+			calls[name]++
+			return nil
+		}
+	}
+	healthchecks := []*health{
+		newHealth("db", "help", checker("db"), HealthGroup("storage")),
+		newHealth("schema", "help", checker("schema"), HealthGroup("migrations"), DependsOn("db")),
+		newHealth("api", "help", checker("api"), HealthGroup("external")),
+	}
+
+	results := checkAll(healthchecks, "migrations")
+	if len(results) != 1 || results[0].metric.Name() != "schema" || results[0].status != healthOk {
+		t.Fatalf("Expected: only schema check result but got %v", results)
+	}
+	if calls["db"] != 1 || calls["schema"] != 1 || calls["api"] != 0 {
+		t.Errorf("Expected: calls for the group checks and dependencies only but got %v", calls)
+	}
+}
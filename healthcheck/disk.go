@@ -0,0 +1,21 @@
+package healthcheck
+
+import (
+	"fmt"
+
+	"go.melnyk.org/metricer"
+)
+
+// DiskFree creates health checker verifying that file system with the path has at least min bytes available
+func DiskFree(path string, min uint64) metricer.HealthcheckFunc {
+	return func() error {
+		free, err := diskFree(path)
+		if err != nil {
+			return err
+		}
+		if free < min {
+			return fmt.Errorf("Free space %d bytes is below threshold %d bytes", free, min)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,7 @@
+// +build !linux,!darwin,!freebsd,!windows
+
+package healthcheck
+
+func diskFree(path string) (uint64, error) {
+	return 0, errNotSupported
+}
@@ -0,0 +1,26 @@
+package healthcheck
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+)
+
+func TestDiskFree(t *testing.T) {
+	dir, err := ioutil.TempDir("", "healthcheck")
+	if err != nil {
+		t.Fatalf("Expected: temp dir, but got %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	if err := DiskFree(dir, 1)(); err != nil {
+		t.Errorf("Expected: no errors, but got %s", err.Error())
+	}
+	if err := DiskFree(dir, math.MaxUint64)(); err == nil {
+		t.Error("Expected: error for unreachable threshold, but got nil")
+	}
+	if err := DiskFree(dir+"/missing", 1)(); err == nil {
+		t.Error("Expected: error for missing path, but got nil")
+	}
+}
@@ -0,0 +1,3 @@
+// Package healthcheck provides health checkers for common dependencies,
+// ready to be used with metricer Host.NewHealthCheck
+package healthcheck
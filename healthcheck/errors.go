@@ -0,0 +1,8 @@
+package healthcheck
+
+import "errors"
+
+var (
+	errNilDB        = errors.New("Database handle cannot be nil")
+	errNotSupported = errors.New("Health check is not supported on this platform")
+)
@@ -0,0 +1,36 @@
+package healthcheck
+
+import (
+	"fmt"
+	"os"
+
+	"go.melnyk.org/metricer"
+)
+
+// FileExists creates health checker verifying that regular file exists
+func FileExists(path string) metricer.HealthcheckFunc {
+	return func() error {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("%s is not a regular file", path)
+		}
+		return nil
+	}
+}
+
+// DirExists creates health checker verifying that directory exists
+func DirExists(path string) metricer.HealthcheckFunc {
+	return func() error {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", path)
+		}
+		return nil
+	}
+}
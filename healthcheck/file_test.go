@@ -0,0 +1,50 @@
+package healthcheck
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileExists(t *testing.T) {
+	dir, err := ioutil.TempDir("", "healthcheck")
+	if err != nil {
+		t.Fatalf("Expected: temp dir, but got %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "file")
+	ioutil.WriteFile(file, []byte("test"), 0600)
+
+	if err := FileExists(file)(); err != nil {
+		t.Errorf("Expected: no errors, but got %s", err.Error())
+	}
+	if err := FileExists(dir)(); err == nil {
+		t.Error("Expected: error for directory, but got nil")
+	}
+	if err := FileExists(filepath.Join(dir, "missing"))(); err == nil {
+		t.Error("Expected: error for missing file, but got nil")
+	}
+}
+
+func TestDirExists(t *testing.T) {
+	dir, err := ioutil.TempDir("", "healthcheck")
+	if err != nil {
+		t.Fatalf("Expected: temp dir, but got %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "file")
+	ioutil.WriteFile(file, []byte("test"), 0600)
+
+	if err := DirExists(dir)(); err != nil {
+		t.Errorf("Expected: no errors, but got %s", err.Error())
+	}
+	if err := DirExists(file)(); err == nil {
+		t.Error("Expected: error for file, but got nil")
+	}
+	if err := DirExists(filepath.Join(dir, "missing"))(); err == nil {
+		t.Error("Expected: error for missing directory, but got nil")
+	}
+}
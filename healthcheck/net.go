@@ -0,0 +1,74 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	"go.melnyk.org/metricer"
+)
+
+const (
+	defaultTimeout = 5 * time.Second // timeout of network checks when it is not set
+	maxDrainSize   = 64 * 1024       // size of response body read to reuse connection
+)
+
+// netTimeout returns default timeout for zero or negative value, so checks do not block forever
+func netTimeout(timeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		return defaultTimeout
+	}
+	return timeout
+}
+
+// TCPDial creates health checker verifying that TCP connection to the address can be established
+func TCPDial(address string, timeout time.Duration) metricer.HealthcheckFunc {
+	return func() error {
+		conn, err := net.DialTimeout("tcp", address, netTimeout(timeout))
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// HTTPGet creates health checker verifying that GET request to the url returns expected status code
+func HTTPGet(url string, status int, timeout time.Duration) metricer.HealthcheckFunc {
+	client := &http.Client{Timeout: netTimeout(timeout)}
+
+	return func() error {
+		resp, err := client.Get(url)
+		if err != nil {
+			return err
+		}
+		// body is drained, so keep-alive connection is reused by the next check
+		io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxDrainSize))
+		resp.Body.Close()
+
+		if resp.StatusCode != status {
+			return fmt.Errorf("Unexpected status code %d, expected %d", resp.StatusCode, status)
+		}
+		return nil
+	}
+}
+
+// DNSResolve creates health checker verifying that host name can be resolved
+func DNSResolve(host string, timeout time.Duration) metricer.HealthcheckFunc {
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), netTimeout(timeout))
+		defer cancel()
+
+		addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+		if err != nil {
+			return err
+		}
+		if len(addrs) == 0 {
+			return fmt.Errorf("No addresses found for %s", host)
+		}
+		return nil
+	}
+}
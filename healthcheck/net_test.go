@@ -0,0 +1,98 @@
+package healthcheck
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTCPDial(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected: listener, but got %s", err.Error())
+	}
+	address := ln.Addr().String()
+
+	if err := TCPDial(address, time.Second)(); err != nil {
+		t.Errorf("Expected: no errors, but got %s", err.Error())
+	}
+
+	ln.Close()
+	if err := TCPDial(address, time.Second)(); err == nil {
+		t.Error("Expected: error for closed listener, but got nil")
+	}
+}
+
+func TestHTTPGet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		url    string
+		status int
+		failed bool
+	}{
+		{server.URL + "/ok", http.StatusOK, false},
+		{server.URL + "/fail", http.StatusOK, true},
+		{server.URL + "/fail", http.StatusInternalServerError, false},
+		{"http://127.0.0.1:0/", http.StatusOK, true},
+	}
+
+	for i, v := range tests {
+		err := HTTPGet(v.url, v.status, time.Second)()
+		if failed := err != nil; failed != v.failed {
+			t.Errorf("Expected (%d): failed %t, but got error %v", i, v.failed, err)
+		}
+	}
+}
+
+func TestHTTPGetReuseConnection(t *testing.T) {
+	var connections int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(make([]byte, 48*1024))
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&connections, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	// zero timeout is replaced by default one
+	check := HTTPGet(server.URL, http.StatusOK, 0)
+	for i := 0; i < 3; i++ {
+		if err := check(); err != nil {
+			t.Fatalf("Expected: no errors, but got %s", err.Error())
+		}
+	}
+	if n := atomic.LoadInt32(&connections); n != 1 {
+		t.Errorf("Expected: 1 connection, but got %d", n)
+	}
+}
+
+func TestNetTimeout(t *testing.T) {
+	if timeout := netTimeout(0); timeout != defaultTimeout {
+		t.Errorf("Expected: %s, but got %s", defaultTimeout, timeout)
+	}
+	if timeout := netTimeout(time.Second); timeout != time.Second {
+		t.Errorf("Expected: %s, but got %s", time.Second, timeout)
+	}
+}
+
+func TestDNSResolve(t *testing.T) {
+	if err := DNSResolve("localhost", time.Second)(); err != nil {
+		t.Errorf("Expected: no errors, but got %s", err.Error())
+	}
+
+	if err := DNSResolve("metricer.invalid", time.Second)(); err == nil {
+		t.Error("Expected: error for invalid host, but got nil")
+	}
+}
@@ -0,0 +1,30 @@
+package healthcheck
+
+import (
+	"fmt"
+	"runtime"
+
+	"go.melnyk.org/metricer"
+)
+
+// Goroutines creates health checker verifying that number of goroutines does not exceed max
+func Goroutines(max int) metricer.HealthcheckFunc {
+	return func() error {
+		if n := runtime.NumGoroutine(); n > max {
+			return fmt.Errorf("Number of goroutines %d exceeds threshold %d", n, max)
+		}
+		return nil
+	}
+}
+
+// HeapAlloc creates health checker verifying that allocated heap memory does not exceed max bytes
+func HeapAlloc(max uint64) metricer.HealthcheckFunc {
+	return func() error {
+		var memstats runtime.MemStats
+		runtime.ReadMemStats(&memstats)
+		if memstats.HeapAlloc > max {
+			return fmt.Errorf("Heap allocation %d bytes exceeds threshold %d bytes", memstats.HeapAlloc, max)
+		}
+		return nil
+	}
+}
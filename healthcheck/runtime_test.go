@@ -0,0 +1,24 @@
+package healthcheck
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGoroutines(t *testing.T) {
+	if err := Goroutines(math.MaxInt32)(); err != nil {
+		t.Errorf("Expected: no errors, but got %s", err.Error())
+	}
+	if err := Goroutines(0)(); err == nil {
+		t.Error("Expected: error for zero threshold, but got nil")
+	}
+}
+
+func TestHeapAlloc(t *testing.T) {
+	if err := HeapAlloc(math.MaxUint64)(); err != nil {
+		t.Errorf("Expected: no errors, but got %s", err.Error())
+	}
+	if err := HeapAlloc(0)(); err == nil {
+		t.Error("Expected: error for zero threshold, but got nil")
+	}
+}
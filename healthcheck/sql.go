@@ -0,0 +1,23 @@
+package healthcheck
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.melnyk.org/metricer"
+)
+
+// SQLPing creates health checker verifying connection to the database
+func SQLPing(db *sql.DB, timeout time.Duration) metricer.HealthcheckFunc {
+	return func() error {
+		if db == nil {
+			return errNilDB
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		return db.PingContext(ctx)
+	}
+}
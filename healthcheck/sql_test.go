@@ -0,0 +1,69 @@
+package healthcheck
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errPing = errors.New("ping failed")
+
+type testDriver struct{}
+
+func (d testDriver) Open(name string) (driver.Conn, error) {
+	return &testConn{fail: name == "fail"}, nil
+}
+
+type testConn struct {
+	fail bool
+}
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *testConn) Close() error {
+	return nil
+}
+
+func (c *testConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *testConn) Ping(ctx context.Context) error {
+	if c.fail {
+		return errPing
+	}
+	return nil
+}
+
+func init() {
+	sql.Register("healthcheck-test", testDriver{})
+}
+
+func TestSQLPing(t *testing.T) {
+	db, _ := sql.Open("healthcheck-test", "ok")
+	defer db.Close()
+
+	if err := SQLPing(db, time.Second)(); err != nil {
+		t.Errorf("Expected: no errors, but got %s", err.Error())
+	}
+}
+
+func TestSQLPingFailed(t *testing.T) {
+	db, _ := sql.Open("healthcheck-test", "fail")
+	defer db.Close()
+
+	if err := SQLPing(db, time.Second)(); err != errPing {
+		t.Errorf("Expected: ping error, but got %v", err)
+	}
+}
+
+func TestSQLPingNil(t *testing.T) {
+	if err := SQLPing(nil, time.Second)(); err != errNilDB {
+		t.Errorf("Expected: nil database error, but got %v", err)
+	}
+}
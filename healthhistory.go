@@ -0,0 +1,56 @@
+package metricer
+
+import (
+	"sync"
+	"time"
+)
+
+const healthHistorySize = 128
+
+type healthTransition struct {
+	Time   time.Time `json:"time"`
+	Check  string    `json:"check"`
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// healthHistory keeps bounded list of the last health status changes
+type healthHistory struct {
+	mu      sync.Mutex
+	entries []healthTransition
+	next    int
+	full    bool
+}
+
+func newHealthHistory(size int) *healthHistory {
+	return &healthHistory{entries: make([]healthTransition, size)}
+}
+
+func (history *healthHistory) add(entry healthTransition) {
+	history.mu.Lock()
+	history.entries[history.next] = entry
+	history.next++
+	if history.next == len(history.entries) {
+		history.next = 0
+		history.full = true
+	}
+	history.mu.Unlock()
+}
+
+// list returns history entries starting from the oldest one
+func (history *healthHistory) list() []healthTransition {
+	history.mu.Lock()
+	defer history.mu.Unlock()
+
+	if !history.full {
+		list := make([]healthTransition, history.next)
+		copy(list, history.entries[:history.next])
+		return list
+	}
+
+	list := make([]healthTransition, 0, len(history.entries))
+	list = append(list, history.entries[history.next:]...)
+	list = append(list, history.entries[:history.next]...)
+	return list
+}
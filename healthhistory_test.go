@@ -0,0 +1,38 @@
+package metricer
+
+import (
+	"testing"
+)
+
+// TestHealthHistoryPartial validates history list before buffer is full
+func TestHealthHistoryPartial(t *testing.T) {
+	history := newHealthHistory(4)
+	history.add(healthTransition{Check: "1"})
+	history.add(healthTransition{Check: "2"})
+
+	list := history.list()
+	if len(list) != 2 {
+		t.Fatalf("Expected: 2 entries but got %d", len(list))
+	}
+	if list[0].Check != "1" || list[1].Check != "2" {
+		t.Errorf("Expected: entries in order of adding but got %v", list)
+	}
+}
+
+// TestHealthHistoryBounded validates history keeps only the last entries
+func TestHealthHistoryBounded(t *testing.T) {
+	history := newHealthHistory(3)
+	for _, v := range []string{"1", "2", "3", "4", "5"} {
+		history.add(healthTransition{Check: v})
+	}
+
+	list := history.list()
+	if len(list) != 3 {
+		t.Fatalf("Expected: 3 entries but got %d", len(list))
+	}
+	for i, v := range []string{"3", "4", "5"} {
+		if list[i].Check != v {
+			t.Errorf("Expected (%d): %s but got %s", i, v, list[i].Check)
+		}
+	}
+}
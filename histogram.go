@@ -0,0 +1,140 @@
+package metricer
+
+import (
+	"math"
+	"sort"
+	"sync/atomic"
+)
+
+// DefaultBuckets are default histogram buckets, tailored to measure duration in seconds
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type histogram struct {
+	count   uint64
+	sumbits uint64
+	name    string
+	help    string
+	buckets []float64 // upper bounds, sorted
+	counts  []uint64  // per bucket, not cumulative
+	native  *nativeHistogram
+
+	exemplars []atomic.Value // *exemplar per bucket, the last one is +Inf bucket
+	metadata
+}
+
+type histogramSnapshot struct {
+	buckets []float64 // upper bounds without +Inf
+	counts  []uint64  // cumulative counts
+	count   uint64
+	sum     float64
+	native  *nativeSnapshot // native histograms only
+
+	exemplars []*exemplar // per bucket including +Inf, nil when bucket has no exemplar
+}
+
+func newHistogram(name string, help string, buckets []float64) *histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	bounds := make([]float64, 0, len(buckets))
+	for _, v := range buckets {
+		if !math.IsInf(v, +1) && !math.IsNaN(v) {
+			bounds = append(bounds, v)
+		}
+	}
+	sort.Float64s(bounds)
+
+	return &histogram{
+		name:    name,
+		help:    help,
+		buckets: bounds,
+		counts:  make([]uint64, len(bounds)),
+
+		exemplars: make([]atomic.Value, len(bounds)+1),
+	}
+}
+
+func (metric *histogram) Name() string {
+	return metric.name
+}
+
+func (metric *histogram) Help() string {
+	return metric.help
+}
+
+func (metric *histogram) Observe(v float64) {
+	// NaN cannot be placed into any bucket and makes sum unusable
+	if math.IsNaN(v) {
+		return
+	}
+
+	if metric.native != nil {
+		metric.native.observe(v)
+	}
+
+	if i := sort.SearchFloat64s(metric.buckets, v); i < len(metric.counts) {
+		atomic.AddUint64(&metric.counts[i], 1)
+	}
+
+	for {
+		old := atomic.LoadUint64(&metric.sumbits)
+		sum := math.Float64bits(math.Float64frombits(old) + v)
+		if atomic.CompareAndSwapUint64(&metric.sumbits, old, sum) {
+			break
+		}
+	}
+
+	atomic.AddUint64(&metric.count, 1)
+}
+
+// ObserveWithExemplar adds observation and attaches exemplar labels (e.g. trace_id, span_id) to its bucket
+func (metric *histogram) ObserveWithExemplar(v float64, labels map[string]string) {
+	metric.Observe(v)
+	if e := newExemplar(labels, v); e != nil {
+		metric.exemplars[sort.SearchFloat64s(metric.buckets, v)].Store(e)
+	}
+}
+
+func (metric *histogram) Count() uint64 {
+	return atomic.LoadUint64(&metric.count)
+}
+
+func (metric *histogram) Sum() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&metric.sumbits))
+}
+
+func (metric *histogram) snapshot() *histogramSnapshot {
+	s := &histogramSnapshot{
+		buckets: metric.buckets,
+		counts:  make([]uint64, len(metric.counts)),
+	}
+
+	var cumulative uint64
+	for i := range metric.counts {
+		cumulative += atomic.LoadUint64(&metric.counts[i])
+		s.counts[i] = cumulative
+	}
+
+	s.count = metric.Count()
+	s.sum = metric.Sum()
+	if metric.native != nil {
+		s.native = metric.native.snapshot()
+	}
+
+	for i := range metric.exemplars {
+		if e, ok := metric.exemplars[i].Load().(*exemplar); ok {
+			if s.exemplars == nil {
+				s.exemplars = make([]*exemplar, len(metric.exemplars))
+			}
+			s.exemplars[i] = e
+		}
+	}
+
+	// observations can come between reading buckets and the total count
+	if s.count < cumulative {
+		s.count = cumulative
+	}
+
+	return s
+}
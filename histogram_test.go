@@ -0,0 +1,120 @@
+package metricer
+
+import (
+	"math"
+	"testing"
+)
+
+// registerHistogram adds histogram metric to the host, histograms without native buckets are not created by
+// public API, so tests use it
+func (h *host) registerHistogram(name string, help string, buckets []float64, options ...MetricOption) Histogram {
+	source := callSite()
+	o := applyMetricOptions(options)
+	metric := newHistogram(name, help, buckets)
+	metric.metadata = o.metadata
+	h.checkMetadata(name, o.metadata)
+	h.mu.Lock()
+	h.metrics = append(h.metrics, metric)
+	h.sources[metric] = source
+	h.mu.Unlock()
+	return metric
+}
+
+// TestHistogramObserve validates observe call for histogram metric
+func TestHistogramObserve(t *testing.T) {
+	metric := newHistogram("histogram-name", "help", []float64{1, 5, 10})
+	metric.Observe(0.5)
+	metric.Observe(1)
+	metric.Observe(7)
+	metric.Observe(100)
+
+	if val := metric.Count(); val != 4 {
+		t.Errorf("Expected: 4 but got %d", val)
+	}
+	if val := metric.Sum(); val != 108.5 {
+		t.Errorf("Expected: 108.5 but got %f", val)
+	}
+
+	snap := metric.snapshot()
+	expected := []uint64{2, 2, 3}
+	for i, v := range expected {
+		if snap.counts[i] != v {
+			t.Errorf("Expected (%d): %d but got %d", i, v, snap.counts[i])
+		}
+	}
+	if snap.count != 4 {
+		t.Errorf("Expected: 4 but got %d", snap.count)
+	}
+}
+
+// TestHistogramDefaultBuckets validates default buckets for histogram metric
+func TestHistogramDefaultBuckets(t *testing.T) {
+	metric := newHistogram("histogram-name", "help", nil)
+	if len(metric.buckets) != len(DefaultBuckets) {
+		t.Errorf("Expected: %d buckets but got %d", len(DefaultBuckets), len(metric.buckets))
+	}
+}
+
+// TestHistogramBucketsSorted validates buckets order for histogram metric
+func TestHistogramBucketsSorted(t *testing.T) {
+	metric := newHistogram("histogram-name", "help", []float64{10, 1, 5})
+	for i := 1; i < len(metric.buckets); i++ {
+		if metric.buckets[i-1] > metric.buckets[i] {
+			t.Fatalf("Expected: sorted buckets but got %v", metric.buckets)
+		}
+	}
+}
+
+// TestHistogramName validates returning name for histogram
+func TestHistogramName(t *testing.T) {
+	metric := newHistogram("histogram-name", "help", nil)
+	if val := metric.Name(); val != "histogram-name" {
+		t.Errorf("Expected: histogram-name but got %s", val)
+	}
+}
+
+// TestHistogramHelp validates returning help for histogram
+func TestHistogramHelp(t *testing.T) {
+	metric := newHistogram("histogram-name", "help", nil)
+	if val := metric.Help(); val != "help" {
+		t.Errorf("Expected: help but got %s", val)
+	}
+}
+
+// TestHistogramObserveWithExemplar validates exemplars of histogram buckets
+func TestHistogramObserveWithExemplar(t *testing.T) {
+	metric := newHistogram("histogram-name", "help", []float64{1, 5})
+	metric.ObserveWithExemplar(3, map[string]string{"trace_id": "abc"})
+	metric.ObserveWithExemplar(7, map[string]string{"trace_id": "def"})
+
+	snap := metric.snapshot()
+	if snap.count != 2 {
+		t.Errorf("Expected: 2 but got %d", snap.count)
+	}
+	if len(snap.exemplars) != 3 {
+		t.Fatalf("Expected: 3 exemplars but got %d", len(snap.exemplars))
+	}
+	if snap.exemplars[0] != nil {
+		t.Errorf("Expected: no exemplar but got %v", snap.exemplars[0])
+	}
+	if e := snap.exemplars[1]; e == nil || e.value != 3 {
+		t.Errorf("Expected: exemplar of 3 but got %v", e)
+	}
+	if e := snap.exemplars[2]; e == nil || e.value != 7 {
+		t.Errorf("Expected: exemplar of 7 in +Inf bucket but got %v", e)
+	}
+}
+
+// TestHistogramObserveNaN validates NaN observations are dropped
+func TestHistogramObserveNaN(t *testing.T) {
+	metric := newHistogram("histogram-name", "help", []float64{1})
+	metric.Observe(math.NaN())
+	metric.Observe(0.5)
+
+	if val := metric.Count(); val != 1 {
+		t.Errorf("Expected: 1 but got %d", val)
+	}
+	if val := metric.Sum(); val != 0.5 {
+		t.Errorf("Expected: 0.5 but got %f", val)
+	}
+}
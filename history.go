@@ -0,0 +1,317 @@
+package metricer
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go.melnyk.org/mlog"
+)
+
+// HistoryConfig represents configuration of in-memory history of metrics values
+type HistoryConfig struct {
+	// Resolution is interval between samples
+	Resolution time.Duration `json:"resolution,omitempty" yaml:"resolution"`
+
+	// Retention is period of time samples are kept for, it is limited to 8640 samples of every series
+	Retention time.Duration `json:"retention,omitempty" yaml:"retention"`
+}
+
+// Validate checks history config structure
+func (config *HistoryConfig) Validate() error {
+	if config.Resolution <= 0 {
+		config.Resolution = defaultHistoryResolution
+	}
+	if config.Retention <= 0 {
+		config.Retention = defaultHistoryRetention
+	}
+	if config.Retention < config.Resolution {
+		return errHistoryRetention
+	}
+
+	// retention is limited by number of samples kept for every series
+	if config.Retention/config.Resolution > maxHistorySamples {
+		config.Retention = config.Resolution * maxHistorySamples
+	}
+	return nil
+}
+
+type historySample struct {
+	time  time.Time
+	value float64
+}
+
+// historySeries keeps bounded list of the last samples of series, the list grows up to the size
+// and then the oldest samples are overwritten
+type historySeries struct {
+	labels  map[string]string
+	counter bool
+	size    int
+	samples []historySample
+	next    int // position of the oldest sample when the list is full
+}
+
+func (series *historySeries) add(sample historySample) {
+	if len(series.samples) < series.size {
+		series.samples = append(series.samples, sample)
+		return
+	}
+
+	series.samples[series.next] = sample
+	series.next++
+	if series.next == len(series.samples) {
+		series.next = 0
+	}
+}
+
+// list returns samples starting from the oldest one
+func (series *historySeries) list() []historySample {
+	list := make([]historySample, 0, len(series.samples))
+	list = append(list, series.samples[series.next:]...)
+	list = append(list, series.samples[:series.next]...)
+	return list
+}
+
+// last returns the newest sample
+func (series *historySeries) last() historySample {
+	if series.next == 0 {
+		return series.samples[len(series.samples)-1]
+	}
+	return series.samples[series.next-1]
+}
+
+// metricHistory samples values of all metrics, series are stored by sample name and labels
+type metricHistory struct {
+	config HistoryConfig
+	size   int
+
+	mu     sync.RWMutex
+	series map[string]map[string]*historySeries
+}
+
+func newMetricHistory(config HistoryConfig) *metricHistory {
+	return &metricHistory{
+		config: config,
+		size:   int(config.Retention / config.Resolution),
+		series: make(map[string]map[string]*historySeries),
+	}
+}
+
+func (history *metricHistory) name() string {
+	return "history"
+}
+
+func (history *metricHistory) interval() time.Duration {
+	return history.config.Resolution
+}
+
+func (history *metricHistory) close() error {
+	return nil
+}
+
+func (history *metricHistory) push(snap *snapshot) error {
+	history.mu.Lock()
+	defer history.mu.Unlock()
+
+	for _, f := range snap.families {
+		// histogram buckets, sum and count grow like counters
+		counter := f.kind != kindGauge
+		single := &snapshot{families: []family{f}}
+		single.samples(func(name string, labels []labelPair, value float64) {
+			byLabels, ok := history.series[name]
+			if !ok {
+				byLabels = make(map[string]*historySeries)
+				history.series[name] = byLabels
+			}
+
+			key := formatLabels(labels)
+			series, ok := byLabels[key]
+			if !ok {
+				series = &historySeries{labels: make(map[string]string), counter: counter, size: history.size}
+				for _, v := range labels {
+					series.labels[v.name] = v.value
+				}
+				byLabels[key] = series
+			}
+			series.add(historySample{time: snap.time, value: value})
+		})
+	}
+
+	// series not updated within retention period belong to removed metrics
+	for name, byLabels := range history.series {
+		for key, series := range byLabels {
+			if snap.time.Sub(series.last().time) > history.config.Retention {
+				delete(byLabels, key)
+			}
+		}
+		if len(byLabels) == 0 {
+			delete(history.series, name)
+		}
+	}
+	return nil
+}
+
+type historyPoint struct {
+	Time  time.Time  `json:"time"`
+	Value jsonFloat  `json:"value"`
+	Rate  *jsonFloat `json:"rate,omitempty"` // per second change of counter since the previous sample
+}
+
+type historyResult struct {
+	Labels map[string]string `json:"labels"`
+	Type   string            `json:"type"`
+	Points []historyPoint    `json:"points"`
+}
+
+// query returns samples of all series with the name taken since the time, false is returned for unknown name
+func (history *metricHistory) query(name string, since time.Time) ([]historyResult, bool) {
+	history.mu.RLock()
+	defer history.mu.RUnlock()
+
+	byLabels, ok := history.series[name]
+	if !ok {
+		return nil, false
+	}
+
+	keys := make([]string, 0, len(byLabels))
+	for key := range byLabels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	results := make([]historyResult, 0, len(keys))
+	for _, key := range keys {
+		series := byLabels[key]
+		result := historyResult{Labels: series.labels, Type: kindGauge.String(), Points: []historyPoint{}}
+		if series.counter {
+			result.Type = kindCounter.String()
+		}
+
+		var previous *historySample
+		for _, sample := range series.list() {
+			if !sample.time.Before(since) {
+				point := historyPoint{Time: sample.time, Value: jsonFloat(sample.value)}
+				if series.counter && previous != nil {
+					point.Rate = counterRate(*previous, sample)
+				}
+				result.Points = append(result.Points, point)
+			}
+			sample := sample
+			previous = &sample
+		}
+		results = append(results, result)
+	}
+	return results, true
+}
+
+// recent returns values of the last n samples of all series keyed by name with labels, e.g. name{a="b"}
+func (history *metricHistory) recent(n int) map[string][]jsonFloat {
+	history.mu.RLock()
+	defer history.mu.RUnlock()
+
+	recent := make(map[string][]jsonFloat)
+	for name, byLabels := range history.series {
+		for key, series := range byLabels {
+			samples := series.list()
+			if len(samples) > n {
+				samples = samples[len(samples)-n:]
+			}
+
+			values := make([]jsonFloat, len(samples))
+			for i, v := range samples {
+				values[i] = jsonFloat(v.value)
+			}
+
+			// series without labels are keyed by name only
+			if key == "{}" {
+				key = ""
+			}
+			recent[name+key] = values
+		}
+	}
+	return recent
+}
+
+// counterRate calculates per second rate between samples, decreased value means counter reset
+func counterRate(previous historySample, current historySample) *jsonFloat {
+	elapsed := current.time.Sub(previous.time).Seconds()
+	if elapsed <= 0 {
+		return nil
+	}
+
+	delta := current.value - previous.value
+	if delta < 0 {
+		delta = current.value
+	}
+	rate := jsonFloat(delta / elapsed)
+	return &rate
+}
+
+// parseSince accepts RFC 3339 time or duration back from now
+func parseSince(v string, now time.Time) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+func (h *host) metricsHistory(w http.ResponseWriter, r *http.Request) {
+	if !h.enterHandler(w, r) {
+		return
+	}
+	defer h.wg.Done()
+
+	// only GET method is allowed
+	if r.Method != http.MethodGet {
+		h.handlerError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if h.history == nil {
+		h.handlerError(w, r, http.StatusNotFound, "History is not enabled")
+		return
+	}
+
+	query := r.URL.Query()
+	name := query.Get("name")
+	if name == "" {
+		h.handlerError(w, r, http.StatusBadRequest, "Metric name is required")
+		return
+	}
+
+	since, err := parseSince(query.Get("since"), time.Now())
+	if err != nil {
+		h.handlerError(w, r, http.StatusBadRequest, "Incorrect since value")
+		return
+	}
+
+	h.log.Event(mlog.Verbose, func(e mlog.Event) {
+		e.String("msg", "Get metrics history")
+		e.String("remote", r.RemoteAddr)
+		e.String("name", name)
+	})
+
+	results, ok := h.history.query(name, since)
+	if !ok {
+		h.handlerError(w, r, http.StatusNotFound, "Metric not found")
+		return
+	}
+
+	data := struct {
+		Name       string          `json:"name"`
+		Resolution string          `json:"resolution"`
+		Series     []historyResult `json:"series"`
+	}{
+		Name:       name,
+		Resolution: h.history.config.Resolution.String(),
+		Series:     results,
+	}
+
+	w.Header().Set("Content-Type", contenttypeJSON)
+	json.NewEncoder(w).Encode(data)
+}
@@ -0,0 +1,218 @@
+package metricer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.melnyk.org/mlog/testlog"
+)
+
+func TestHistoryConfig(t *testing.T) {
+	cfg := &HistoryConfig{}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected: no errors, but got %s", err.Error())
+	}
+	if cfg.Resolution != defaultHistoryResolution || cfg.Retention != defaultHistoryRetention {
+		t.Errorf("Expected: default values, but got %s and %s", cfg.Resolution, cfg.Retention)
+	}
+
+	cfg = &HistoryConfig{Resolution: time.Minute, Retention: time.Second}
+	if err := cfg.Validate(); err != errHistoryRetention {
+		t.Errorf("Expected: %v, but got %v", errHistoryRetention, err)
+	}
+
+	cfg = &HistoryConfig{Resolution: time.Second, Retention: 720 * time.Hour}
+	cfg.Validate()
+	if cfg.Retention != maxHistorySamples*time.Second {
+		t.Errorf("Expected: retention limited by number of samples, but got %s", cfg.Retention)
+	}
+}
+
+func TestHistorySeriesGrowth(t *testing.T) {
+	series := &historySeries{size: 3}
+	start := time.Now()
+
+	for i := 0; i < 5; i++ {
+		series.add(historySample{time: start.Add(time.Duration(i) * time.Second), value: float64(i)})
+		if size := len(series.samples); size != i+1 && size != 3 {
+			t.Errorf("Expected (%d): samples allocated on demand, but got %d", i, size)
+		}
+	}
+
+	list := series.list()
+	if len(list) != 3 || list[0].value != 2 || list[2].value != 4 || series.last().value != 4 {
+		t.Errorf("Expected: the last 3 samples, but got %v", list)
+	}
+}
+
+func TestHistoryRing(t *testing.T) {
+	history := newMetricHistory(HistoryConfig{Resolution: time.Second, Retention: 3 * time.Second})
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	gauge := mhost.NewGauge("connections", "connections help")
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		gauge.Update(int64(i))
+		snap := mhost.collect()
+		snap.time = start.Add(time.Duration(i) * time.Second)
+		history.push(snap)
+	}
+
+	results, ok := history.query("connections", time.Time{})
+	if !ok || len(results) != 1 {
+		t.Fatalf("Expected: one series, but got %v", results)
+	}
+
+	// only the last samples within retention are kept
+	points := results[0].Points
+	if len(points) != 3 || points[0].Value != 2 || points[2].Value != 4 {
+		t.Errorf("Expected: 3 last points, but got %v", points)
+	}
+	if results[0].Type != "gauge" || points[0].Rate != nil {
+		t.Errorf("Expected: gauge without rate, but got %v", results[0])
+	}
+
+	if results, _ := history.query("connections", start.Add(4*time.Second)); len(results[0].Points) != 1 {
+		t.Errorf("Expected: points since the time, but got %v", results[0].Points)
+	}
+
+	if _, ok := history.query("unknown", time.Time{}); ok {
+		t.Error("Expected: unknown metric, but got result")
+	}
+}
+
+func TestHistoryCounterRate(t *testing.T) {
+	history := newMetricHistory(HistoryConfig{Resolution: time.Second, Retention: time.Minute})
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	counter := mhost.NewCounter("requests", "requests help")
+	mhost.registerHistogram("latency", "latency help", []float64{1})
+
+	start := time.Now()
+	tests := []struct {
+		inc  int64
+		rate float64
+	}{
+		{0, 0},
+		{10, 5}, // 10 requests in 2 seconds
+		{4, 2},
+	}
+
+	for i, v := range tests {
+		counter.Inc(v.inc)
+		snap := mhost.collect()
+		snap.time = start.Add(time.Duration(2*i) * time.Second)
+		history.push(snap)
+	}
+
+	results, _ := history.query("requests", time.Time{})
+	points := results[0].Points
+	if results[0].Type != "counter" || len(points) != len(tests) {
+		t.Fatalf("Expected: counter points, but got %v", results[0])
+	}
+	for i, v := range tests[1:] {
+		if points[i+1].Rate == nil || float64(*points[i+1].Rate) != v.rate {
+			t.Errorf("Expected (%d): rate %f, but got %v", i, v.rate, points[i+1].Rate)
+		}
+	}
+
+	// rate of the first point within the range is based on the previous sample
+	results, _ = history.query("requests", start.Add(3*time.Second))
+	if len(results[0].Points) != 1 || *results[0].Points[0].Rate != 2 {
+		t.Errorf("Expected: rate of the last point, but got %v", results[0].Points)
+	}
+
+	// histograms are stored by samples
+	if results, ok := history.query("latency_bucket", time.Time{}); !ok || len(results) != 2 || results[0].Type != "counter" {
+		t.Errorf("Expected: bucket series, but got %v", results)
+	}
+}
+
+func TestCounterRateReset(t *testing.T) {
+	start := time.Now()
+	rate := counterRate(historySample{time: start, value: 10}, historySample{time: start.Add(time.Second), value: 3})
+	if rate == nil || *rate != 3 {
+		t.Errorf("Expected: rate after reset, but got %v", rate)
+	}
+
+	if rate := counterRate(historySample{time: start, value: 1}, historySample{time: start, value: 2}); rate != nil {
+		t.Errorf("Expected: nil, but got %f", *rate)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		since time.Time
+		err   bool
+	}{
+		{"", time.Time{}, false},
+		{"5m", now.Add(-5 * time.Minute), false},
+		{"2020-01-01T11:00:00Z", now.Add(-time.Hour), false},
+		{"yesterday", time.Time{}, true},
+	}
+
+	for i, v := range tests {
+		since, err := parseSince(v.value, now)
+		if (err != nil) != v.err || !since.Equal(v.since) {
+			t.Errorf("Expected (%d): %s, but got %s (%v)", i, v.since, since, err)
+		}
+	}
+}
+
+func TestServerMetricsHistory(t *testing.T) {
+	mhost := NewHost(&Config{History: &HistoryConfig{}}, testlog.NewLogbook()).(*host)
+	mhost.NewCounter("requests", "requests help").Inc(3)
+	mhost.history.push(mhost.collect())
+
+	tests := []struct {
+		url  string
+		code int
+	}{
+		{"http://test/metrics/history?name=requests&since=1m", http.StatusOK},
+		{"http://test/metrics/history", http.StatusBadRequest},
+		{"http://test/metrics/history?name=requests&since=yesterday", http.StatusBadRequest},
+		{"http://test/metrics/history?name=unknown", http.StatusNotFound},
+	}
+
+	for i, v := range tests {
+		req := httptest.NewRequest("GET", v.url, nil)
+		w := httptest.NewRecorder()
+		mhost.metricsHistory(w, req)
+
+		if w.Code != v.code {
+			t.Errorf("Expected (%d): %d, but got %d", i, v.code, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "http://test/metrics/history?name=requests", nil)
+	w := httptest.NewRecorder()
+	mhost.metricsHistory(w, req)
+
+	var data struct {
+		Name   string          `json:"name"`
+		Series []historyResult `json:"series"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&data); err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+	if data.Name != "requests" || len(data.Series) != 1 || len(data.Series[0].Points) != 1 || data.Series[0].Points[0].Value != 3 {
+		t.Errorf("Expected: history of requests, but got %v", data)
+	}
+}
+
+func TestServerMetricsHistoryDisabled(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+
+	req := httptest.NewRequest("GET", "http://test/metrics/history?name=requests", nil)
+	w := httptest.NewRecorder()
+	mhost.metricsHistory(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected: %d, but got %d", http.StatusNotFound, w.Code)
+	}
+}
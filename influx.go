@@ -0,0 +1,203 @@
+package metricer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InfluxConfig represents configuration of InfluxDB line protocol exporter
+type InfluxConfig struct {
+	// URL of write endpoint including query parameters, e.g. http://127.0.0.1:8086/write?db=metrics
+	URL string `json:"url,omitempty" yaml:"url"`
+
+	// Token is optional authorization token
+	Token string `json:"token,omitempty" yaml:"token"`
+
+	// Interval between pushes
+	Interval time.Duration `json:"interval,omitempty" yaml:"interval"`
+
+	// Batch is max number of lines sent in one request
+	Batch int `json:"batch,omitempty" yaml:"batch"`
+
+	// Gzip enables compression of request body
+	Gzip bool `json:"gzip,omitempty" yaml:"gzip"`
+
+	// Timeout of one request
+	Timeout time.Duration `json:"timeout,omitempty" yaml:"timeout"`
+}
+
+// Validate checks InfluxDB config structure
+func (config *InfluxConfig) Validate() error {
+	if config.URL == "" {
+		config.URL = defaultInfluxURL
+	}
+	if config.Interval <= 0 {
+		config.Interval = defaultPushInterval
+	}
+	if config.Batch <= 0 {
+		config.Batch = defaultInfluxBatch
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultPushTimeout
+	}
+	return nil
+}
+
+var (
+	influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "\n", `\n`)
+	influxKeyEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", `\n`)
+)
+
+// influxLines formats snapshot in InfluxDB line protocol, labels are written as tags
+func influxLines(snap *snapshot) []string {
+	timestamp := strconv.FormatInt(snap.time.UnixNano(), 10)
+	lines := make([]string, 0, len(snap.families))
+
+	for _, f := range snap.families {
+		for _, s := range f.series {
+			// non-finite values are rejected by InfluxDB with the whole batch, so such fields are skipped
+			// and the line is dropped when no fields are left
+			var fields []string
+			if s.hist == nil {
+				if !math.IsNaN(s.value) && !math.IsInf(s.value, 0) {
+					fields = append(fields, "value="+formatValue(s.value))
+				}
+			} else {
+				fields = append(fields, fmt.Sprintf("count=%di", s.hist.count))
+				if !math.IsNaN(s.hist.sum) && !math.IsInf(s.hist.sum, 0) {
+					fields = append(fields, "sum="+formatValue(s.hist.sum))
+				}
+				for i, bound := range s.hist.buckets {
+					fields = append(fields, fmt.Sprintf("le_%s=%di", influxKeyEscaper.Replace(formatValue(bound)), s.hist.counts[i]))
+				}
+			}
+			if len(fields) == 0 {
+				continue
+			}
+
+			var b strings.Builder
+			b.WriteString(influxMeasurementEscaper.Replace(f.name))
+			for _, list := range [][]labelPair{snap.labels, s.labels} {
+				for _, v := range list {
+					if v.value == "" {
+						continue // empty tag values are not allowed
+					}
+					b.WriteByte(',')
+					b.WriteString(influxKeyEscaper.Replace(v.name))
+					b.WriteByte('=')
+					b.WriteString(influxKeyEscaper.Replace(v.value))
+				}
+			}
+			b.WriteByte(' ')
+			b.WriteString(strings.Join(fields, ","))
+			b.WriteByte(' ')
+			b.WriteString(timestamp)
+			lines = append(lines, b.String())
+		}
+	}
+
+	return lines
+}
+
+func (h *host) metricsInInflux(w http.ResponseWriter, r *http.Request) {
+	for _, line := range influxLines(h.collect()) {
+		io.WriteString(w, line)
+		io.WriteString(w, "\n")
+	}
+}
+
+type influx struct {
+	config InfluxConfig
+	client *http.Client
+}
+
+func newInflux(config InfluxConfig) *influx {
+	return &influx{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+	}
+}
+
+func (exporter *influx) name() string {
+	return "influx"
+}
+
+func (exporter *influx) interval() time.Duration {
+	return exporter.config.Interval
+}
+
+func (exporter *influx) close() error {
+	exporter.client.CloseIdleConnections()
+	return nil
+}
+
+func (exporter *influx) push(snap *snapshot) error {
+	lines := influxLines(snap)
+
+	for len(lines) > 0 {
+		batch := lines
+		if len(batch) > exporter.config.Batch {
+			batch = batch[:exporter.config.Batch]
+		}
+		lines = lines[len(batch):]
+
+		if err := exporter.write(batch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// write sends batch of lines to the write endpoint
+func (exporter *influx) write(lines []string) error {
+	var body bytes.Buffer
+	var w io.Writer = &body
+
+	var zw *gzip.Writer
+	if exporter.config.Gzip {
+		zw = gzip.NewWriter(&body)
+		w = zw
+	}
+	for _, line := range lines {
+		io.WriteString(w, line)
+		io.WriteString(w, "\n")
+	}
+	if zw != nil {
+		zw.Close()
+	}
+
+	req, err := http.NewRequest(http.MethodPost, exporter.config.URL, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contenttypeText)
+	if exporter.config.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if exporter.config.Token != "" {
+		req.Header.Set("Authorization", "Token "+exporter.config.Token)
+	}
+
+	resp, err := exporter.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("InfluxDB write failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	io.Copy(ioutil.Discard, resp.Body)
+	return nil
+}
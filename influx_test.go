@@ -0,0 +1,164 @@
+package metricer
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.melnyk.org/mlog/testlog"
+)
+
+func TestInfluxLines(t *testing.T) {
+	snap := &snapshot{
+		time:   time.Unix(1, 5),
+		labels: []labelPair{{name: "host", value: "a b"}, {name: "empty", value: ""}},
+		families: []family{
+			{name: "requests", kind: kindCounter, series: []series{{value: 10}}},
+			{name: "status", kind: kindGauge, series: []series{{labels: []labelPair{{name: "check", value: "db,main"}}, value: 1}}},
+			{name: "latency", kind: kindHistogram, series: []series{{hist: &histogramSnapshot{buckets: []float64{0.5}, counts: []uint64{2}, count: 3, sum: 1.5}}}},
+		},
+	}
+
+	expected := []string{
+		`requests,host=a\ b value=10 1000000005`,
+		`status,host=a\ b,check=db\,main value=1 1000000005`,
+		`latency,host=a\ b count=3i,sum=1.5,le_0.5=2i 1000000005`,
+	}
+
+	lines := influxLines(snap)
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected: %d lines, but got %d", len(expected), len(lines))
+	}
+	for i, v := range expected {
+		if lines[i] != v {
+			t.Errorf("Expected (%d): %s, but got %s", i, v, lines[i])
+		}
+	}
+}
+
+func TestInfluxLinesNonFinite(t *testing.T) {
+	snap := &snapshot{
+		time: time.Unix(1, 5),
+		families: []family{
+			{name: "ratio", kind: kindGauge, series: []series{{value: math.NaN()}}},
+			{name: "status", kind: kindGauge, series: []series{{value: 1}}},
+			{name: "latency", kind: kindHistogram, series: []series{{hist: &histogramSnapshot{buckets: []float64{0.5}, counts: []uint64{2}, count: 3, sum: math.Inf(1)}}}},
+		},
+	}
+
+	expected := []string{
+		`status value=1 1000000005`,
+		`latency count=3i,le_0.5=2i 1000000005`,
+	}
+
+	lines := influxLines(snap)
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected: %d lines, but got %v", len(expected), lines)
+	}
+	for i, v := range expected {
+		if lines[i] != v {
+			t.Errorf("Expected (%d): %s, but got %s", i, v, lines[i])
+		}
+	}
+}
+
+func TestServerMetricsValuesInflux(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewCounter("counter", "counter help").Inc(5)
+
+	tests := []struct {
+		url    string
+		accept string
+	}{
+		{"http://test/metrics/values?format=influx", ""},
+		{"http://test/metrics/values", "application/x-influxdb-line-protocol"},
+	}
+
+	for i, v := range tests {
+		req := httptest.NewRequest("GET", v.url, nil)
+		if v.accept != "" {
+			req.Header.Set("Accept", v.accept)
+		}
+		w := httptest.NewRecorder()
+		mhost.metricsValues(w, req)
+
+		body, _ := ioutil.ReadAll(w.Result().Body)
+		if !strings.Contains(string(body), "counter,_os="+goos+" value=5 ") {
+			t.Errorf("Expected (%d): counter in line protocol, but got %s", i, body)
+		}
+	}
+}
+
+func TestInfluxPush(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	lines := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+
+		if r.Header.Get("Authorization") != "Token secret" {
+			t.Errorf("Expected: authorization token, but got %s", r.Header.Get("Authorization"))
+		}
+		if r.Header.Get("Content-Encoding") != "gzip" {
+			t.Errorf("Expected: gzip encoding, but got %s", r.Header.Get("Content-Encoding"))
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Fatalf("Expected: gzip body, but got %s", err.Error())
+		}
+		scanner := bufio.NewScanner(zr)
+		for scanner.Scan() {
+			lines++
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	exporter := newInflux(InfluxConfig{URL: server.URL + "/write?db=test", Token: "secret", Batch: 2, Gzip: true, Timeout: time.Second})
+	defer exporter.close()
+
+	snap := mhost.collect()
+	if err := exporter.push(snap); err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	total := len(influxLines(snap))
+	if lines != total {
+		t.Errorf("Expected: %d lines, but got %d", total, lines)
+	}
+	if requests != (total+1)/2 {
+		t.Errorf("Expected: %d requests, but got %d", (total+1)/2, requests)
+	}
+}
+
+func TestInfluxPushFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(ioutil.Discard, r.Body)
+		http.Error(w, "database not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	cfg := InfluxConfig{URL: server.URL + "/write?db=test"}
+	cfg.Validate()
+	exporter := newInflux(cfg)
+	defer exporter.close()
+
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	err := exporter.push(mhost.collect())
+	if err == nil || !strings.Contains(err.Error(), "database not found") {
+		t.Errorf("Expected: error with server message, but got %v", err)
+	}
+}
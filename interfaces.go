@@ -1,5 +1,7 @@
 package metricer
 
+import "context"
+
 // Metric provides interface to general metrics
 type Metric interface {
 	Name() string
@@ -15,6 +17,13 @@ type Health interface {
 	Check() error
 }
 
+// HealthCheck provides interface to manage registered health check
+type HealthCheck interface {
+	Health
+	Remove()
+	Replace(HealthcheckFunc)
+}
+
 // Label provides interface to label metrics
 type Label interface {
 	Metric
@@ -27,6 +36,7 @@ type Counter interface {
 	Metric
 	Reset()
 	Inc(int64)
+	IncWithExemplar(int64, map[string]string)
 	Dec(int64)
 	Count() int64
 }
@@ -38,14 +48,47 @@ type Gauge interface {
 	Value() int64
 }
 
+// Histogram provides interface to metrics with observe method
+type Histogram interface {
+	Metric
+	Observe(float64)
+	ObserveWithExemplar(float64, map[string]string)
+	Count() uint64
+	Sum() float64
+}
+
+// Meter provides interface to metrics measuring rate of events per second
+type Meter interface {
+	Metric
+	Mark(int64)
+	Count() int64
+	Rate1() float64
+	Rate5() float64
+	Rate15() float64
+	RateMean() float64
+}
+
 // Host represents metric host interface
 type Host interface {
 	Start() error
 	Stop() error
 
-	NewHealthCheck(string, string, HealthcheckFunc)
+	SetDraining(bool)
+
+	Push(context.Context, string, string, map[string]string) error
+	PushAdd(context.Context, string, string, map[string]string) error
+	Delete(context.Context, string, string, map[string]string) error
+
+	NewHealthCheck(string, string, HealthcheckFunc, ...HealthOption) (HealthCheck, error)
 
 	NewLabel(string, string) Label
-	NewGauge(string, string) Gauge
-	NewCounter(string, string) Counter
+	NewGauge(string, string, ...MetricOption) Gauge
+	NewCounter(string, string, ...MetricOption) Counter
+	NewNativeHistogram(string, string, []float64, float64, ...MetricOption) Histogram
+	NewMeter(string, string, ...MetricOption) Meter
+
+	Catalog() []CatalogEntry
+
+	CollectExpvar(string)
+	PublishExpvar(string) error
 }
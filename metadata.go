@@ -0,0 +1,108 @@
+package metricer
+
+import (
+	"strings"
+
+	"go.melnyk.org/mlog"
+)
+
+// Stability represents stability level of metric
+type Stability string
+
+// Stability levels of metrics
+const (
+	StabilityAlpha  Stability = "alpha"
+	StabilityStable Stability = "stable"
+)
+
+// metadata keeps optional description of metric set on registration
+type metadata struct {
+	unit            string
+	stability       Stability
+	deprecatedSince string
+	replacement     string
+}
+
+func (m metadata) meta() metadata {
+	return m
+}
+
+func (m metadata) deprecated() bool {
+	return m.deprecatedSince != ""
+}
+
+// describe adds stability level and deprecation notice to help, e.g. [STABLE] (Deprecated since 1.2, use new_name) help
+func (m metadata) describe(help string) string {
+	if m.deprecated() {
+		notice := "(Deprecated since " + m.deprecatedSince
+		if m.replacement != "" {
+			notice += ", use " + m.replacement
+		}
+		help = notice + ") " + help
+	}
+	if m.stability != "" {
+		help = "[" + strings.ToUpper(string(m.stability)) + "] " + help
+	}
+	return help
+}
+
+// metadataOf returns metadata of metric, empty metadata is returned for metrics without it
+func metadataOf(metric interface{}) metadata {
+	if v, ok := metric.(interface{ meta() metadata }); ok {
+		return v.meta()
+	}
+	return metadata{}
+}
+
+// Unit sets unit of metric values (e.g. seconds, bytes), metric name should end with the unit
+func Unit(unit string) MetricOption {
+	return func(o *metricOptions) {
+		o.unit = unit
+	}
+}
+
+// StabilityLevel sets stability level of metric
+func StabilityLevel(level Stability) MetricOption {
+	return func(o *metricOptions) {
+		o.stability = level
+	}
+}
+
+// Deprecated marks metric as deprecated since the version, replacement is name of metric to use instead
+func Deprecated(since string, replacement string) MetricOption {
+	return func(o *metricOptions) {
+		o.deprecatedSince = since
+		o.replacement = replacement
+	}
+}
+
+// checkMetadata logs warnings for deprecated metrics (once per name) and units not matching metric name
+func (h *host) checkMetadata(name string, m metadata) {
+	if m.unit != "" && !strings.HasSuffix(strings.TrimSuffix(name, "_total"), "_"+m.unit) {
+		h.log.Event(mlog.Warning, func(e mlog.Event) {
+			e.String("msg", "Metric name does not end with its unit")
+			e.String("metric", name)
+			e.String("unit", m.unit)
+		})
+	}
+
+	if !m.deprecated() {
+		return
+	}
+
+	h.mu.Lock()
+	warned := h.deprecated[name]
+	h.deprecated[name] = true
+	h.mu.Unlock()
+
+	if !warned {
+		h.log.Event(mlog.Warning, func(e mlog.Event) {
+			e.String("msg", "Deprecated metric is registered")
+			e.String("metric", name)
+			e.String("since", m.deprecatedSince)
+			if m.replacement != "" {
+				e.String("replacement", m.replacement)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package metricer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go.melnyk.org/mlog/testlog"
+)
+
+func TestMetadataDescribe(t *testing.T) {
+	tests := []struct {
+		meta metadata
+		help string
+	}{
+		{metadata{}, "help"},
+		{metadata{stability: StabilityStable}, "[STABLE] help"},
+		{metadata{deprecatedSince: "1.2"}, "(Deprecated since 1.2) help"},
+		{metadata{stability: StabilityAlpha, deprecatedSince: "1.2", replacement: "new_name"}, "[ALPHA] (Deprecated since 1.2, use new_name) help"},
+	}
+
+	for i, v := range tests {
+		if help := v.meta.describe("help"); help != v.help {
+			t.Errorf("Expected (%d): %s, but got %s", i, v.help, help)
+		}
+	}
+}
+
+func TestMetadataOptions(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	metrics := []interface{}{
+		mhost.NewCounter("counter_seconds_total", "help", Unit("seconds"), StabilityLevel(StabilityStable)),
+		mhost.NewGauge("gauge_bytes", "help", Unit("bytes"), Deprecated("1.2", "new_gauge")),
+		mhost.registerHistogram("histogram_seconds", "help", nil, Unit("seconds")),
+		mhost.NewNativeHistogram("native_seconds", "help", nil, 1.1, Unit("seconds")),
+	}
+
+	expected := []metadata{
+		{unit: "seconds", stability: StabilityStable},
+		{unit: "bytes", deprecatedSince: "1.2", replacement: "new_gauge"},
+		{unit: "seconds"},
+		{unit: "seconds"},
+	}
+
+	for i, v := range metrics {
+		if meta := metadataOf(v); meta != expected[i] {
+			t.Errorf("Expected (%d): %v, but got %v", i, expected[i], meta)
+		}
+	}
+
+	if !mhost.deprecated["gauge_bytes"] || mhost.deprecated["counter_seconds_total"] {
+		t.Errorf("Expected: only deprecated metrics are tracked, but got %v", mhost.deprecated)
+	}
+	if meta := metadataOf(mhost.NewLabel("label", "help")); meta != (metadata{}) {
+		t.Errorf("Expected: empty metadata, but got %v", meta)
+	}
+}
+
+func TestMetadataOpenMetrics(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewCounter("duration_seconds_total", "duration help", Unit("seconds"))
+	mhost.NewGauge("size", "size help", Unit("bytes"))
+	mhost.NewGauge("old", "old help", Deprecated("1.2", "new"))
+
+	var b bytes.Buffer
+	writeOpenMetrics(&b, mhost.collect())
+	body := b.String()
+
+	tests := []string{
+		"# TYPE duration_seconds counter\n# HELP duration_seconds duration help\n# UNIT duration_seconds seconds\n",
+		"# HELP old (Deprecated since 1.2, use new) old help\n",
+	}
+	for i, v := range tests {
+		if !strings.Contains(body, v) {
+			t.Errorf("Expected (%d): %s, but got %s", i, v, body)
+		}
+	}
+	if strings.Contains(body, "# UNIT size") {
+		t.Errorf("Expected: no unit for name without unit suffix, but got %s", body)
+	}
+}
+
+func TestMetadataHideDeprecated(t *testing.T) {
+	mhost := NewHost(&Config{HideDeprecated: true}, testlog.NewLogbook()).(*host)
+	mhost.NewGauge("old", "old help", Deprecated("1.2", "new"))
+	mhost.NewGauge("new", "new help")
+
+	names := make(map[string]bool)
+	for _, f := range mhost.collect().families {
+		names[f.name] = true
+	}
+	if names["old"] || !names["new"] {
+		t.Errorf("Expected: deprecated metric is hidden, but got %v", names)
+	}
+}
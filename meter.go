@@ -0,0 +1,161 @@
+package metricer
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Windows of exponentially weighted moving averages of meter
+var meterWindows = []struct {
+	label  string
+	minute float64
+}{
+	{"1m", 1},
+	{"5m", 5},
+	{"15m", 15},
+}
+
+// ewma is exponentially weighted moving average of per second rate updated every tick
+type ewma struct {
+	alpha       float64
+	rate        float64
+	initialized bool
+}
+
+func newEWMA(minutes float64) ewma {
+	return ewma{alpha: 1 - math.Exp(-meterTickInterval.Minutes()/minutes)}
+}
+
+// update applies rate of the tick, the first tick initializes average
+func (avg *ewma) update(instant float64) {
+	if !avg.initialized {
+		avg.rate = instant
+		avg.initialized = true
+		return
+	}
+	avg.rate += avg.alpha * (instant - avg.rate)
+}
+
+// decay applies ticks without events
+func (avg *ewma) decay(ticks int) {
+	if avg.initialized && ticks > 0 {
+		avg.rate *= math.Pow(1-avg.alpha, float64(ticks))
+	}
+}
+
+// meter measures rate of events, averages are updated lazily on mark and read for all elapsed ticks
+type meter struct {
+	name string
+	help string
+	metadata
+
+	mu        sync.Mutex
+	count     int64
+	uncounted int64 // events since the last tick
+	started   time.Time
+	lastTick  time.Time
+	averages  []ewma
+}
+
+func newMeter(name string, help string, now time.Time) *meter {
+	metric := &meter{name: name, help: help, started: now, lastTick: now}
+	for _, w := range meterWindows {
+		metric.averages = append(metric.averages, newEWMA(w.minute))
+	}
+	return metric
+}
+
+func (metric *meter) Name() string {
+	return metric.name
+}
+
+func (metric *meter) Help() string {
+	return metric.help
+}
+
+// Mark registers n events
+func (metric *meter) Mark(n int64) {
+	metric.mark(n, time.Now())
+}
+
+func (metric *meter) Count() int64 {
+	metric.mu.Lock()
+	defer metric.mu.Unlock()
+	return metric.count
+}
+
+// Rate1 returns one-minute moving average rate of events per second
+func (metric *meter) Rate1() float64 {
+	return metric.rates(time.Now())[0]
+}
+
+// Rate5 returns five-minute moving average rate of events per second
+func (metric *meter) Rate5() float64 {
+	return metric.rates(time.Now())[1]
+}
+
+// Rate15 returns fifteen-minute moving average rate of events per second
+func (metric *meter) Rate15() float64 {
+	return metric.rates(time.Now())[2]
+}
+
+// RateMean returns mean rate of events per second since creation of meter
+func (metric *meter) RateMean() float64 {
+	return metric.rates(time.Now())[len(meterWindows)]
+}
+
+func (metric *meter) mark(n int64, now time.Time) {
+	metric.mu.Lock()
+	metric.tick(now)
+	metric.count += n
+	metric.uncounted += n
+	metric.mu.Unlock()
+}
+
+// rates returns moving averages in order of windows followed by mean rate
+func (metric *meter) rates(now time.Time) []float64 {
+	metric.mu.Lock()
+	defer metric.mu.Unlock()
+
+	metric.tick(now)
+
+	rates := make([]float64, 0, len(metric.averages)+1)
+	for _, avg := range metric.averages {
+		rates = append(rates, avg.rate)
+	}
+
+	mean := 0.0
+	if elapsed := now.Sub(metric.started).Seconds(); elapsed > 0 {
+		mean = float64(metric.count) / elapsed
+	}
+	return append(rates, mean)
+}
+
+// tick updates averages for ticks elapsed since the last one, events are accounted in the first of them
+func (metric *meter) tick(now time.Time) {
+	ticks := int(now.Sub(metric.lastTick) / meterTickInterval)
+	if ticks <= 0 {
+		return
+	}
+	metric.lastTick = metric.lastTick.Add(time.Duration(ticks) * meterTickInterval)
+
+	instant := float64(metric.uncounted) / meterTickInterval.Seconds()
+	metric.uncounted = 0
+	for i := range metric.averages {
+		metric.averages[i].update(instant)
+		metric.averages[i].decay(ticks - 1)
+	}
+}
+
+// family returns rates as gauge series labeled with window
+func (metric *meter) family(now time.Time) family {
+	rates := metric.rates(now)
+
+	f := family{name: metric.name, help: metric.help, kind: kindGauge}
+	for i, w := range meterWindows {
+		f.series = append(f.series, series{labels: []labelPair{{name: "window", value: w.label}}, value: rates[i]})
+	}
+	f.series = append(f.series, series{labels: []labelPair{{name: "window", value: "mean"}}, value: rates[len(meterWindows)]})
+	return f
+}
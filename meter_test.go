@@ -0,0 +1,119 @@
+package metricer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.melnyk.org/mlog/testlog"
+)
+
+func TestMeterConstantRate(t *testing.T) {
+	start := time.Now()
+	metric := newMeter("requests", "requests help", start)
+
+	// 10 events per second during 30 minutes
+	now := start
+	for i := 0; i < 30*60; i++ {
+		metric.mark(10, now)
+		now = now.Add(time.Second)
+	}
+
+	rates := metric.rates(now)
+	for i, v := range rates {
+		if math.Abs(v-10) > 0.1 {
+			t.Errorf("Expected (%d): rate 10, but got %f", i, v)
+		}
+	}
+	if metric.Count() != 10*30*60 {
+		t.Errorf("Expected: %d, but got %d", 10*30*60, metric.Count())
+	}
+}
+
+func TestMeterDecay(t *testing.T) {
+	start := time.Now()
+	metric := newMeter("requests", "requests help", start)
+	metric.mark(50, start)
+
+	// the first tick accounts events as rate of the tick
+	rates := metric.rates(start.Add(meterTickInterval))
+	if rates[0] != 10 || rates[1] != 10 || rates[2] != 10 {
+		t.Errorf("Expected: initial rate 10, but got %v", rates)
+	}
+
+	// one minute without events
+	rates = metric.rates(start.Add(meterTickInterval + time.Minute))
+	if math.Abs(rates[0]-10/math.E) > 0.01 {
+		t.Errorf("Expected: 1m rate %f, but got %f", 10/math.E, rates[0])
+	}
+	if !(rates[0] < rates[1] && rates[1] < rates[2] && rates[2] < 10) {
+		t.Errorf("Expected: longer windows decay slower, but got %v", rates)
+	}
+	if mean := rates[3]; math.Abs(mean-50/65.0) > 0.001 {
+		t.Errorf("Expected: mean rate %f, but got %f", 50/65.0, mean)
+	}
+}
+
+func TestMeterNoTicks(t *testing.T) {
+	start := time.Now()
+	metric := newMeter("requests", "requests help", start)
+	metric.mark(5, start.Add(time.Second))
+
+	if rates := metric.rates(start.Add(2 * time.Second)); rates[0] != 0 || rates[3] != 2.5 {
+		t.Errorf("Expected: no moving average before the first tick, but got %v", rates)
+	}
+}
+
+func TestMeterFamily(t *testing.T) {
+	metric := newMeter("requests", "requests help", time.Now())
+
+	f := metric.family(time.Now())
+	if f.kind != kindGauge || len(f.series) != 4 {
+		t.Fatalf("Expected: 4 gauge series, but got %v", f)
+	}
+
+	windows := []string{"1m", "5m", "15m", "mean"}
+	for i, v := range windows {
+		if labels := f.series[i].labels; len(labels) != 1 || labels[0].name != "window" || labels[0].value != v {
+			t.Errorf("Expected (%d): window %s, but got %v", i, v, labels)
+		}
+	}
+}
+
+func TestServerMetricsValuesMeter(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewMeter("requests", "requests help").Mark(1)
+
+	req := httptest.NewRequest("GET", "http://test/metrics/values", nil)
+	req.Header.Set("Accept", acceptText)
+	w := httptest.NewRecorder()
+	mhost.metricsValues(w, req)
+
+	body, _ := ioutil.ReadAll(w.Result().Body)
+	for _, v := range []string{"# TYPE requests gauge", `requests{_os=`, `window="1m"`, `window="5m"`, `window="15m"`, `window="mean"`} {
+		if !strings.Contains(string(body), v) {
+			t.Errorf("Expected: %s, but got %s", v, body)
+		}
+	}
+
+	req = httptest.NewRequest("GET", "http://test/metrics/values", nil)
+	req.Header.Set("Accept", acceptJSON)
+	w = httptest.NewRecorder()
+	mhost.metricsValues(w, req)
+
+	var data struct {
+		Metrics map[string]interface{} `json:"metrics"`
+	}
+	if err := json.NewDecoder(w.Result().Body).Decode(&data); err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+	for _, v := range []string{`requests{window="1m"}`, `requests{window="5m"}`, `requests{window="15m"}`, `requests{window="mean"}`} {
+		if _, ok := data.Metrics[v]; !ok {
+			t.Errorf("Expected: %s, but got %v", v, data.Metrics)
+		}
+	}
+}
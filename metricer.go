@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.melnyk.org/mlog"
@@ -18,15 +19,40 @@ type host struct {
 
 	config Config
 
+	draining int32 // drain mode, readiness is reported as failed
+
+	streams int32 // number of active metrics streams
+
 	logbook mlog.Logbook
 	log     mlog.Logger
 
 	server *http.Server
 	wg     sync.WaitGroup
+	done   chan struct{} // closed on stop to interrupt long running requests
+	once   sync.Once
+
+	stopmu  sync.Mutex
+	stopped bool // set on stop, requests are not added to wait group after it
 
 	mu           sync.RWMutex
 	metrics      []interface{}
-	healthchecks []Health
+	healthchecks []*health
+
+	healthhistory *healthHistory
+
+	refreshmu sync.Mutex
+	refreshed time.Time // time of the last health checks run shared by gRPC watchers
+
+	pushers []pusher
+
+	history *metricHistory // sampled values of metrics, nil when history is not enabled
+
+	restored   *checkpointData // values of persistent metrics loaded from checkpoint file
+	persistent []interface{}
+
+	deprecated map[string]bool // names of registered deprecated metrics
+
+	sources map[interface{}]string // registration call sites of metrics and health checks
 
 	rtgoroutines       Gauge
 	rtmemalloc         Gauge
@@ -37,8 +63,12 @@ type host struct {
 func NewHost(config *Config, lb mlog.Logbook) Host {
 	h := &host{}
 	h.metrics = make([]interface{}, 0, 16)
-	h.healthchecks = make([]Health, 0, 8)
+	h.healthchecks = make([]*health, 0, 8)
+	h.healthhistory = newHealthHistory(healthHistorySize)
 	h.started = time.Now()
+	h.done = make(chan struct{})
+	h.deprecated = make(map[string]bool)
+	h.sources = make(map[interface{}]string)
 
 	// initialize logger and keep logbook for exposing api
 	if lb == nil {
@@ -47,17 +77,52 @@ func NewHost(config *Config, lb mlog.Logbook) Host {
 	h.logbook = lb
 	h.log = lb.Joiner().Join(logname)
 
+	// invalid sections are disabled by validation, default config is used only instead of nil one
 	if err := config.Validate(); err != nil {
 		h.log.Event(mlog.Warning, func(e mlog.Event) {
 			e.String("msg", "Config validation problem")
 			e.String("error", err.Error())
 		})
-		config = &Config{
-			Port: defaultPort,
+		if config == nil {
+			config = &Config{}
+			config.Validate()
 		}
 	}
 	h.config = *config
 
+	// restore values of persistent metrics
+	h.restored = newCheckpointData()
+	if h.config.Checkpoint != nil {
+		h.restoreCheckpoint()
+		h.pushers = append(h.pushers, newCheckpointer(*h.config.Checkpoint, h.checkpointValues))
+	}
+
+	// history is sampled by the same loop as exporters
+	if h.config.History != nil {
+		h.history = newMetricHistory(*h.config.History)
+		h.pushers = append(h.pushers, h.history)
+	}
+
+	// create exporters
+	if h.config.Statsd != nil {
+		h.pushers = append(h.pushers, newStatsd(*h.config.Statsd))
+	}
+	if h.config.Graphite != nil {
+		h.pushers = append(h.pushers, newGraphite(*h.config.Graphite))
+	}
+	if h.config.Influx != nil {
+		h.pushers = append(h.pushers, newInflux(*h.config.Influx))
+	}
+	if h.config.Otlp != nil {
+		h.pushers = append(h.pushers, newOtlp(*h.config.Otlp))
+	}
+	if h.config.File != nil {
+		h.pushers = append(h.pushers, newFileSink(*h.config.File))
+	}
+	if h.config.RemoteWrite != nil {
+		h.pushers = append(h.pushers, newRemoteWrite(*h.config.RemoteWrite))
+	}
+
 	// create runtime metrics
 	rtos := h.NewLabel(rtmetricos, rtmetricoshelp)
 	rtos.Update(runtime.GOOS)
@@ -71,39 +136,169 @@ func NewHost(config *Config, lb mlog.Logbook) Host {
 	return h
 }
 
+// updateRuntime updates runtime metrics
+func (h *host) updateRuntime() {
+	var memstats runtime.MemStats
+	runtime.ReadMemStats(&memstats)
+	h.rtmemalloc.Update(int64(memstats.Alloc))
+	h.rtgoroutines.Update(int64(runtime.NumGoroutine()))
+}
+
 // NewLabel creates new named label metric inside metrics collection
 func (h *host) NewLabel(name string, help string) Label {
+	source := callSite()
 	metric := &label{name: name, help: help}
 	h.mu.Lock()
 	h.metrics = append(h.metrics, metric)
+	h.sources[metric] = source
 	h.mu.Unlock()
 	return metric
 }
 
 // NewCounter creates new named counter metric inside metrics collection
-func (h *host) NewCounter(name string, help string) Counter {
-	metric := &counter{name: name, help: help}
+func (h *host) NewCounter(name string, help string, options ...MetricOption) Counter {
+	source := callSite()
+	o := applyMetricOptions(options)
+	metric := &counter{name: name, help: help, metadata: o.metadata}
+	h.checkMetadata(name, o.metadata)
 	h.mu.Lock()
+	if o.persistent {
+		metric.value = h.restored.Counters[name]
+		h.persistent = append(h.persistent, metric)
+	}
 	h.metrics = append(h.metrics, metric)
+	h.sources[metric] = source
 	h.mu.Unlock()
 	return metric
 }
 
 // NewGauge creates new named gauge metric inside metrics collection
-func (h *host) NewGauge(name string, help string) Gauge {
-	metric := &gauge{name: name, help: help}
+func (h *host) NewGauge(name string, help string, options ...MetricOption) Gauge {
+	source := callSite()
+	o := applyMetricOptions(options)
+	metric := &gauge{name: name, help: help, metadata: o.metadata}
+	h.checkMetadata(name, o.metadata)
+	h.mu.Lock()
+	if o.persistent {
+		metric.value = h.restored.Gauges[name]
+		h.persistent = append(h.persistent, metric)
+	}
+	h.metrics = append(h.metrics, metric)
+	h.sources[metric] = source
+	h.mu.Unlock()
+	return metric
+}
+
+// NewNativeHistogram creates new named histogram metric which also keeps exponential buckets
+// of Prometheus native histogram, growth factor of the buckets is not greater than the factor
+func (h *host) NewNativeHistogram(name string, help string, buckets []float64, factor float64, options ...MetricOption) Histogram {
+	source := callSite()
+	o := applyMetricOptions(options)
+	metric := newHistogram(name, help, buckets)
+	metric.native = newNativeHistogram(factor)
+	metric.metadata = o.metadata
+	h.checkMetadata(name, o.metadata)
+	h.mu.Lock()
+	h.metrics = append(h.metrics, metric)
+	h.sources[metric] = source
+	h.mu.Unlock()
+	return metric
+}
+
+// NewMeter creates new named meter metric exposing rates of events per second as gauge series labeled with window
+func (h *host) NewMeter(name string, help string, options ...MetricOption) Meter {
+	source := callSite()
+	o := applyMetricOptions(options)
+	metric := newMeter(name, help, time.Now())
+	metric.metadata = o.metadata
+	h.checkMetadata(name, o.metadata)
 	h.mu.Lock()
 	h.metrics = append(h.metrics, metric)
+	h.sources[metric] = source
 	h.mu.Unlock()
 	return metric
 }
 
-// NewHealthCheck creates new named health checker
-func (h *host) NewHealthCheck(name string, help string, checker HealthcheckFunc) {
-	metric := &health{name: name, help: help, checker: checker}
+// NewHealthCheck creates new named health checker, names of health checks must be unique
+func (h *host) NewHealthCheck(name string, help string, checker HealthcheckFunc, options ...HealthOption) (HealthCheck, error) {
+	source := callSite()
+	metric := newHealth(name, help, checker, options...)
+	metric.onTransition = h.healthTransition
+	metric.onRemove = h.removeHealthCheck
+
 	h.mu.Lock()
+	for _, v := range h.healthchecks {
+		if v.Name() == name {
+			h.mu.Unlock()
+			h.log.Event(mlog.Warning, func(e mlog.Event) {
+				e.String("msg", "Health check with the same name already exists")
+				e.String("metric", name)
+			})
+			return nil, errHealthCheckExists
+		}
+	}
 	h.healthchecks = append(h.healthchecks, metric)
+	h.sources[metric] = source
 	h.mu.Unlock()
+
+	return metric, nil
+}
+
+// removeHealthCheck removes health checker from the host
+func (h *host) removeHealthCheck(metric *health) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// build new list, so copies taken by readers are not affected
+	healthchecks := make([]*health, 0, len(h.healthchecks))
+	for _, v := range h.healthchecks {
+		if v != metric {
+			healthchecks = append(healthchecks, v)
+		}
+	}
+	h.healthchecks = healthchecks
+	delete(h.sources, metric)
+}
+
+// healthTransition keeps track of health check status changes
+func (h *host) healthTransition(metric *health, from, to healthStatus, err error) {
+	entry := healthTransition{
+		Time:  time.Now(),
+		Check: metric.Name(),
+		From:  from.String(),
+		To:    to.String(),
+	}
+	if err != nil {
+		entry.Reason = err.Error()
+	}
+
+	h.healthhistory.add(entry)
+
+	h.log.Event(mlog.Info, func(e mlog.Event) {
+		e.String("msg", "Health check status changed")
+		e.String("metric", entry.Check)
+		e.String("from", entry.From)
+		e.String("to", entry.To)
+	})
+}
+
+// SetDraining enables or disables drain mode, health check is reported as failed in drain mode
+func (h *host) SetDraining(draining bool) {
+	var v int32
+	if draining {
+		v = 1
+	}
+
+	if atomic.SwapInt32(&h.draining, v) != v {
+		h.log.Event(mlog.Info, func(e mlog.Event) {
+			e.String("msg", "Drain mode changed")
+			e.String("draining", fmt.Sprint(draining))
+		})
+	}
+}
+
+func (h *host) isDraining() bool {
+	return atomic.LoadInt32(&h.draining) != 0
 }
 
 func (h *host) Start() error {
@@ -114,6 +309,8 @@ func (h *host) Start() error {
 
 	h.log.Info("Starting Metricer...")
 
+	h.startPushers()
+
 	h.wg.Add(1)
 	wait := make(chan struct{}, 1)
 
@@ -175,9 +372,26 @@ func (h *host) Start() error {
 func (h *host) Stop() error {
 	h.log.Info("Stopping Merticer...")
 
+	if h.server != nil && h.config.DrainOnStop {
+		h.SetDraining(true)
+		h.log.Info("Waiting for drain grace period...")
+		time.Sleep(h.config.DrainGrace)
+	}
+
+	// new requests are rejected, so wait group is not increased while waiting for it
+	h.stopmu.Lock()
+	h.stopped = true
+	h.stopmu.Unlock()
+
+	// interrupt long running requests
+	h.once.Do(func() {
+		close(h.done)
+	})
+
 	if h.server != nil {
-		ctx, _ := context.WithTimeout(context.Background(), 5*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		h.server.Shutdown(ctx)
+		cancel()
 	}
 
 	h.wg.Wait()
@@ -1,7 +1,12 @@
 package metricer
 
 import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
 
 	"go.melnyk.org/mlog/testlog"
 )
@@ -70,3 +75,116 @@ func TestMetricerStartStop(t *testing.T) {
 	mhost.Start()
 	mhost.Stop()
 }
+
+func TestMetricerSetDraining(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook())
+	mhost.SetDraining(true)
+	if !mhost.(*host).isDraining() {
+		t.Error("Expected: drain mode is enabled")
+	}
+	mhost.SetDraining(false)
+	if mhost.(*host).isDraining() {
+		t.Error("Expected: drain mode is disabled")
+	}
+}
+
+func TestMetricerStopWithDrain(t *testing.T) {
+	cfg := &Config{DrainOnStop: true, DrainGrace: 10 * time.Millisecond}
+	mhost := NewHost(cfg, testlog.NewLogbook())
+	mhost.Start()
+	mhost.Stop()
+	if !mhost.(*host).isDraining() {
+		t.Error("Expected: drain mode is enabled on stop")
+	}
+}
+
+func TestMetricerHealthCheckDuplicate(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook())
+	if _, err := mhost.NewHealthCheck("health", "health help", nil); err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+	check, err := mhost.NewHealthCheck("health", "health help", nil)
+	if err != errHealthCheckExists {
+		t.Errorf("Expected: errHealthCheckExists, but got %v", err)
+	}
+	if check != nil {
+		t.Error("Expected: nil health check for duplicate")
+	}
+}
+
+func TestMetricerHealthCheckRemove(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook())
+	check, _ := mhost.NewHealthCheck("health", "health help", nil)
+	mhost.NewHealthCheck("other", "other help", nil)
+
+	check.Remove()
+	check.Remove()
+	if len(mhost.(*host).healthchecks) != 1 {
+		t.Fatalf("Expected: size of healthcheck list equal 1, but size %d", len(mhost.(*host).healthchecks))
+	}
+
+	// name can be registered again after removal
+	if _, err := mhost.NewHealthCheck("health", "health help", nil); err != nil {
+		t.Errorf("Expected: no errors, but got %s", err.Error())
+	}
+}
+
+func TestMetricerHealthCheckReplace(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook())
+	check, _ := mhost.NewHealthCheck("health", "health help", func() error {
+		return errors.New("failed")
+	})
+	if err := check.Check(); err == nil {
+		t.Fatal("Expected: error, but got nil")
+	}
+
+	check.Replace(func() error {
+		return nil
+	})
+	if err := check.Check(); err != nil {
+		t.Errorf("Expected: no errors, but got %s", err.Error())
+	}
+}
+
+func TestMetricerHealthCheckConcurrent(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			check, _ := mhost.NewHealthCheck(fmt.Sprint("health", i), "health help", nil)
+			check.Replace(func() error {
+				return nil
+			})
+			if i%2 == 0 {
+				check.Remove()
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			req := httptest.NewRequest("GET", "http://test/health/check", nil)
+			mhost.healthCheck(httptest.NewRecorder(), req)
+		}
+	}()
+	wg.Wait()
+
+	if len(mhost.healthchecks) != 50 {
+		t.Errorf("Expected: size of healthcheck list equal 50, but size %d", len(mhost.healthchecks))
+	}
+}
+
+func TestNewHostInvalidConfigSection(t *testing.T) {
+	cfg := &Config{Port: 1234, EnableDebug: true, DebugToken: "token", File: &FileConfig{Format: FileFormatCSV}}
+	mhost := NewHost(cfg, testlog.NewLogbook()).(*host)
+
+	if mhost.config.Port != 1234 || !mhost.config.EnableDebug || mhost.config.DebugToken != "token" {
+		t.Errorf("Expected: valid settings kept, but got %v", mhost.config)
+	}
+	if mhost.config.File != nil || len(mhost.pushers) != 0 {
+		t.Errorf("Expected: file sink disabled, but got %v", mhost.pushers)
+	}
+}
@@ -0,0 +1,179 @@
+package metricer
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+)
+
+// Metric types of io.prometheus.client.MetricFamily
+const (
+	protoTypeCounter   = 0
+	protoTypeGauge     = 1
+	protoTypeHistogram = 4
+)
+
+// acceptsProtobuf checks whether Accept header negotiates delimited protobuf format
+func acceptsProtobuf(accept string) bool {
+	for _, v := range strings.Split(accept, ",") {
+		v = strings.Replace(v, " ", "", -1)
+		if strings.HasPrefix(v, acceptProtobuf) && strings.Contains(v, protobufProto) && strings.Contains(v, protobufEncoding) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeProtobuf writes snapshot as io.prometheus.client.MetricFamily messages, every message is prefixed with its length
+func writeProtobuf(w io.Writer, snap *snapshot) error {
+	var size [binary.MaxVarintLen64]byte
+	for _, f := range snap.families {
+		message := metricFamily(snap, f)
+		n := binary.PutUvarint(size[:], uint64(len(message)))
+		if _, err := w.Write(size[:n]); err != nil {
+			return err
+		}
+		if _, err := w.Write(message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// metricFamily encodes family as io.prometheus.client.MetricFamily message, host labels are attached to every metric
+func metricFamily(snap *snapshot, f family) []byte {
+	var b protoBuffer
+	b.string(1, f.name)
+	b.string(2, f.help)
+	switch f.kind {
+	case kindCounter:
+		b.uint64(3, protoTypeCounter)
+	case kindHistogram:
+		b.uint64(3, protoTypeHistogram)
+	default:
+		b.uint64(3, protoTypeGauge)
+	}
+
+	for _, s := range f.series {
+		b.message(4, func(m *protoBuffer) { // Metric
+			for _, list := range [][]labelPair{snap.labels, s.labels} {
+				for _, v := range list {
+					m.message(1, func(label *protoBuffer) { // LabelPair
+						label.string(1, v.name)
+						label.string(2, v.value)
+					})
+				}
+			}
+
+			switch f.kind {
+			case kindCounter:
+				m.message(3, func(c *protoBuffer) { // Counter
+					c.double(1, s.value)
+					if s.exemplar != nil {
+						c.message(2, func(e *protoBuffer) {
+							protoExemplar(e, s.exemplar)
+						})
+					}
+				})
+			case kindHistogram:
+				m.message(7, func(h *protoBuffer) { // Histogram
+					protoHistogram(h, s.hist)
+				})
+			default:
+				m.message(2, func(g *protoBuffer) { // Gauge
+					g.double(1, s.value)
+				})
+			}
+		})
+	}
+
+	if f.unit != "" {
+		b.string(5, f.unit)
+	}
+
+	return b
+}
+
+// protoHistogram encodes fields of io.prometheus.client.Histogram message
+func protoHistogram(b *protoBuffer, hist *histogramSnapshot) {
+	b.uint64(1, hist.count)
+	b.double(2, hist.sum)
+	for i, bound := range hist.buckets {
+		b.message(3, func(bucket *protoBuffer) { // Bucket
+			bucket.uint64(1, hist.counts[i])
+			bucket.double(2, bound)
+			if hist.exemplars != nil && hist.exemplars[i] != nil {
+				bucket.message(3, func(e *protoBuffer) {
+					protoExemplar(e, hist.exemplars[i])
+				})
+			}
+		})
+	}
+
+	native := hist.native
+	if native == nil {
+		return
+	}
+
+	b.sint64(5, int64(native.schema))
+	b.double(6, native.zeroThreshold)
+	b.uint64(7, native.zero)
+
+	protoNativeBuckets(b, 9, 10, native.negative)
+	if len(native.positive) == 0 && len(native.negative) == 0 && native.zero == 0 {
+		// empty span marks histogram without observations as native one
+		b.message(12, func(span *protoBuffer) {
+			span.sint64(1, 0)
+			span.uint64(2, 0)
+		})
+		return
+	}
+	protoNativeBuckets(b, 12, 13, native.positive)
+}
+
+// protoExemplar encodes fields of io.prometheus.client.Exemplar message
+func protoExemplar(b *protoBuffer, e *exemplar) {
+	for _, v := range e.labels {
+		b.message(1, func(label *protoBuffer) { // LabelPair
+			label.string(1, v.name)
+			label.string(2, v.value)
+		})
+	}
+	b.double(2, e.value)
+	b.message(3, func(ts *protoBuffer) { // google.protobuf.Timestamp
+		ts.int64(1, e.time.Unix())
+		ts.int64(2, int64(e.time.Nanosecond()))
+	})
+}
+
+// protoNativeBuckets encodes buckets as spans of consecutive keys and deltas between bucket counts
+func protoNativeBuckets(b *protoBuffer, spanField int, deltaField int, buckets []nativeBucket) {
+	if len(buckets) == 0 {
+		return
+	}
+
+	// spans, offset of the first span is the first key, the next ones are gaps between spans
+	start, last := 0, buckets[0].key-1
+	for i := 1; i <= len(buckets); i++ {
+		if i < len(buckets) && buckets[i].key == buckets[i-1].key+1 {
+			continue
+		}
+		offset := buckets[start].key - last - 1
+		if start == 0 {
+			offset = buckets[0].key
+		}
+		length := i - start
+		b.message(spanField, func(span *protoBuffer) { // BucketSpan
+			span.sint64(1, int64(offset))
+			span.uint64(2, uint64(length))
+		})
+		last = buckets[i-1].key
+		start = i
+	}
+
+	var previous int64
+	for _, v := range buckets {
+		b.sint64(deltaField, int64(v.count)-previous)
+		previous = int64(v.count)
+	}
+}
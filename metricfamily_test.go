@@ -0,0 +1,190 @@
+package metricer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"net/http/httptest"
+	"testing"
+
+	"go.melnyk.org/mlog/testlog"
+)
+
+// decodeDelimited splits delimited stream into messages
+func decodeDelimited(t *testing.T, b []byte) [][]byte {
+	var messages [][]byte
+	for len(b) > 0 {
+		size, n := binary.Uvarint(b)
+		if n <= 0 || uint64(len(b)-n) < size {
+			t.Fatalf("Expected: valid length prefix, but got %v", b)
+		}
+		messages = append(messages, b[n:n+int(size)])
+		b = b[n+int(size):]
+	}
+	return messages
+}
+
+func zigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func TestAcceptsProtobuf(t *testing.T) {
+	tests := []struct {
+		accept string
+		result bool
+	}{
+		{"application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited;q=0.7,text/plain;version=0.0.4;q=0.3,*/*;q=0.1", true},
+		{"application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=delimited", true},
+		{"application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=text", false},
+		{"text/plain;version=0.0.4,application/vnd.google.protobuf", false},
+		{"application/json", false},
+		{"", false},
+	}
+
+	for i, v := range tests {
+		if result := acceptsProtobuf(v.accept); result != v.result {
+			t.Errorf("Expected (%d): %t, but got %t", i, v.result, result)
+		}
+	}
+}
+
+func TestWriteProtobuf(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewCounter("counter", "counter help").Inc(3)
+	mhost.registerHistogram("histogram", "histogram help", []float64{1}).Observe(2)
+
+	var b bytes.Buffer
+	writeProtobuf(&b, mhost.collect())
+
+	found := 0
+	for _, message := range decodeDelimited(t, b.Bytes()) {
+		fields := protoDecode(t, message)
+		name, help, kind := string(fields[0].data), string(fields[1].data), fields[2].value
+
+		switch name {
+		case "counter":
+			found++
+			if help != "counter help" || kind != protoTypeCounter {
+				t.Errorf("Expected: counter family, but got %s %d", help, kind)
+			}
+			metric := protoDecode(t, fields[3].data)
+			label := protoDecode(t, metric[0].data)
+			if string(label[0].data) != rtmetricos || string(label[1].data) != goos {
+				t.Errorf("Expected: host label, but got %v", label)
+			}
+			counter := protoDecode(t, metric[1].data)
+			if metric[1].field != 3 || math.Float64frombits(counter[0].value) != 3 {
+				t.Errorf("Expected: counter value 3, but got %v", metric[1])
+			}
+		case "histogram":
+			found++
+			if kind != protoTypeHistogram {
+				t.Errorf("Expected: histogram family, but got %d", kind)
+			}
+			metric := protoDecode(t, fields[3].data)
+			hist := protoDecode(t, metric[1].data)
+			if metric[1].field != 7 || hist[0].value != 1 || math.Float64frombits(hist[1].value) != 2 {
+				t.Errorf("Expected: histogram count and sum, but got %v", hist)
+			}
+			bucket := protoDecode(t, hist[2].data)
+			if hist[2].field != 3 || bucket[0].value != 0 || math.Float64frombits(bucket[1].value) != 1 {
+				t.Errorf("Expected: histogram bucket, but got %v", bucket)
+			}
+			if len(hist) != 3 {
+				t.Errorf("Expected: no native histogram fields, but got %v", hist)
+			}
+		}
+	}
+	if found != 2 {
+		t.Errorf("Expected: 2 families, but got %d", found)
+	}
+}
+
+func TestProtoNativeHistogram(t *testing.T) {
+	hist := &histogramSnapshot{native: &nativeSnapshot{
+		schema:        3,
+		zeroThreshold: 0.5,
+		zero:          1,
+		positive:      []nativeBucket{{-1, 2}, {0, 1}, {3, 4}},
+		negative:      []nativeBucket{{5, 1}},
+	}}
+
+	var b protoBuffer
+	protoHistogram(&b, hist)
+
+	var spans [][2]int64
+	var deltas []int64
+	var negative []int64
+	for _, f := range protoDecode(t, b) {
+		switch f.field {
+		case 5:
+			if zigzag(f.value) != 3 {
+				t.Errorf("Expected: schema 3, but got %d", zigzag(f.value))
+			}
+		case 6:
+			if math.Float64frombits(f.value) != 0.5 {
+				t.Errorf("Expected: zero threshold 0.5, but got %f", math.Float64frombits(f.value))
+			}
+		case 7:
+			if f.value != 1 {
+				t.Errorf("Expected: zero count 1, but got %d", f.value)
+			}
+		case 9:
+			span := protoDecode(t, f.data)
+			negative = append(negative, zigzag(span[0].value), int64(span[1].value))
+		case 12:
+			span := protoDecode(t, f.data)
+			spans = append(spans, [2]int64{zigzag(span[0].value), int64(span[1].value)})
+		case 13:
+			deltas = append(deltas, zigzag(f.value))
+		}
+	}
+
+	if len(spans) != 2 || spans[0] != [2]int64{-1, 2} || spans[1] != [2]int64{2, 1} {
+		t.Errorf("Expected: spans [-1 2] [2 1], but got %v", spans)
+	}
+	if len(deltas) != 3 || deltas[0] != 2 || deltas[1] != -1 || deltas[2] != 3 {
+		t.Errorf("Expected: deltas [2 -1 3], but got %v", deltas)
+	}
+	if len(negative) != 2 || negative[0] != 5 || negative[1] != 1 {
+		t.Errorf("Expected: negative span [5 1], but got %v", negative)
+	}
+}
+
+func TestServerMetricsValuesProtobuf(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewNativeHistogram("native", "native help", nil, 1.1).Observe(1)
+
+	req := httptest.NewRequest("GET", "http://test/metrics/values", nil)
+	req.Header.Set("Accept", "application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited;q=0.7,text/plain;version=0.0.4;q=0.3")
+	w := httptest.NewRecorder()
+	mhost.metricsValues(w, req)
+
+	resp := w.Result()
+	if resp.Header.Get("Content-Type") != contenttypeProtobufDelimited {
+		t.Errorf("Expected: %s, but got %s", contenttypeProtobufDelimited, resp.Header.Get("Content-Type"))
+	}
+
+	found := false
+	for _, message := range decodeDelimited(t, w.Body.Bytes()) {
+		fields := protoDecode(t, message)
+		if string(fields[0].data) != "native" {
+			continue
+		}
+		found = true
+
+		metric := protoDecode(t, fields[3].data)
+		schema := false
+		for _, f := range protoDecode(t, metric[1].data) {
+			if f.field == 5 && zigzag(f.value) == 3 {
+				schema = true
+			}
+		}
+		if !schema {
+			t.Error("Expected: native histogram schema 3")
+		}
+	}
+	if !found {
+		t.Error("Expected: native histogram family")
+	}
+}
@@ -0,0 +1,103 @@
+package metricer
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// nativeHistogram keeps sparse exponential buckets of Prometheus native histogram,
+// bucket i of the schema s covers (base^(i-1), base^i] where base = 2^(2^-s)
+type nativeHistogram struct {
+	schema        int32
+	zeroThreshold float64
+
+	mu       sync.Mutex
+	zero     uint64
+	positive map[int]uint64
+	negative map[int]uint64
+}
+
+type nativeBucket struct {
+	key   int
+	count uint64
+}
+
+type nativeSnapshot struct {
+	schema        int32
+	zeroThreshold float64
+	zero          uint64
+	positive      []nativeBucket // sorted by key
+	negative      []nativeBucket // sorted by key
+}
+
+// newNativeHistogram creates native histogram with the highest resolution where
+// growth factor of buckets is not greater than the factor
+func newNativeHistogram(factor float64) *nativeHistogram {
+	schema := int32(0)
+	for ; schema < nativeMaxSchema; schema++ {
+		if math.Pow(2, math.Pow(2, -float64(schema))) <= factor {
+			break
+		}
+	}
+
+	return &nativeHistogram{
+		schema:        schema,
+		zeroThreshold: nativeZeroThreshold,
+		positive:      make(map[int]uint64),
+		negative:      make(map[int]uint64),
+	}
+}
+
+// key returns index of the bucket for positive value
+func (native *nativeHistogram) key(v float64) int {
+	// exact powers of two are upper bounds of buckets
+	if frac, exp := math.Frexp(v); frac == 0.5 {
+		return (exp - 1) << uint(native.schema)
+	}
+	return int(math.Ceil(math.Log2(v) * float64(int(1)<<uint(native.schema))))
+}
+
+func (native *nativeHistogram) observe(v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+	if math.IsInf(v, 0) {
+		v = math.Copysign(math.MaxFloat64, v) // the highest bucket
+	}
+
+	native.mu.Lock()
+	switch {
+	case math.Abs(v) <= native.zeroThreshold:
+		native.zero++
+	case v > 0:
+		native.positive[native.key(v)]++
+	default:
+		native.negative[native.key(-v)]++
+	}
+	native.mu.Unlock()
+}
+
+func (native *nativeHistogram) snapshot() *nativeSnapshot {
+	buckets := func(m map[int]uint64) []nativeBucket {
+		list := make([]nativeBucket, 0, len(m))
+		for k, v := range m {
+			list = append(list, nativeBucket{key: k, count: v})
+		}
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].key < list[j].key
+		})
+		return list
+	}
+
+	native.mu.Lock()
+	defer native.mu.Unlock()
+
+	return &nativeSnapshot{
+		schema:        native.schema,
+		zeroThreshold: native.zeroThreshold,
+		zero:          native.zero,
+		positive:      buckets(native.positive),
+		negative:      buckets(native.negative),
+	}
+}
@@ -0,0 +1,83 @@
+package metricer
+
+import (
+	"math"
+	"testing"
+)
+
+// TestNativeHistogramSchema validates schema selection by growth factor
+func TestNativeHistogramSchema(t *testing.T) {
+	tests := []struct {
+		factor float64
+		schema int32
+	}{
+		{2, 0},
+		{4, 0},
+		{1.5, 1},
+		{1.1, 3},
+		{1.0001, nativeMaxSchema},
+		{0, nativeMaxSchema},
+	}
+
+	for i, v := range tests {
+		if schema := newNativeHistogram(v.factor).schema; schema != v.schema {
+			t.Errorf("Expected (%d): %d but got %d", i, v.schema, schema)
+		}
+	}
+}
+
+// TestNativeHistogramKey validates bucket selection for values
+func TestNativeHistogramKey(t *testing.T) {
+	tests := []struct {
+		schema int32
+		value  float64
+		key    int
+	}{
+		{0, 1, 0},
+		{0, 0.75, 0},
+		{0, 2, 1},
+		{0, 3, 2},
+		{0, 0.25, -2},
+		{3, 2, 8},
+		{3, 2.1, 9},
+		{3, 1.05, 1},
+	}
+
+	for i, v := range tests {
+		native := &nativeHistogram{schema: v.schema}
+		if key := native.key(v.value); key != v.key {
+			t.Errorf("Expected (%d): %d but got %d", i, v.key, key)
+		}
+	}
+}
+
+// TestNativeHistogramObserve validates observe call for native histogram
+func TestNativeHistogramObserve(t *testing.T) {
+	native := newNativeHistogram(2)
+	native.observe(0)
+	native.observe(1)
+	native.observe(1)
+	native.observe(3)
+	native.observe(-3)
+	native.observe(math.NaN())
+	native.observe(math.Inf(+1))
+
+	snap := native.snapshot()
+	if snap.zero != 1 {
+		t.Errorf("Expected: 1 but got %d", snap.zero)
+	}
+
+	expected := []nativeBucket{{0, 2}, {2, 1}, {1024, 1}}
+	if len(snap.positive) != len(expected) {
+		t.Fatalf("Expected: %v but got %v", expected, snap.positive)
+	}
+	for i, v := range expected {
+		if snap.positive[i] != v {
+			t.Errorf("Expected (%d): %v but got %v", i, v, snap.positive[i])
+		}
+	}
+
+	if len(snap.negative) != 1 || snap.negative[0] != (nativeBucket{2, 1}) {
+		t.Errorf("Expected: one negative bucket but got %v", snap.negative)
+	}
+}
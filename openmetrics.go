@@ -0,0 +1,66 @@
+package metricer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// acceptsOpenMetrics checks whether OpenMetrics format is requested explicitly, Accept header listing text format
+// as well (e.g. default one of Prometheus) keeps text format, so names of counters are not changed by _total suffix
+func acceptsOpenMetrics(accept string, format string) bool {
+	if format == formatOpenMetrics {
+		return true
+	}
+	return strings.Contains(accept, acceptOpenMetrics) && !strings.Contains(accept, acceptText)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// writeOpenMetrics writes snapshot in OpenMetrics text format with exemplars,
+// counter samples get _total suffix
+func writeOpenMetrics(w io.Writer, snap *snapshot) {
+	for _, f := range snap.families {
+		name := f.name
+		if f.kind == kindCounter {
+			name = strings.TrimSuffix(name, "_total")
+		}
+
+		fmt.Fprintf(w, "# TYPE %s %s\n", name, f.kind)
+		fmt.Fprintf(w, "# HELP %s %s\n", name, helpEscaper.Replace(f.help))
+		if f.unit != "" && strings.HasSuffix(name, "_"+f.unit) {
+			fmt.Fprintf(w, "# UNIT %s %s\n", name, f.unit)
+		}
+
+		for _, s := range f.series {
+			switch {
+			case s.hist != nil:
+				exemplar := func(i int) string {
+					if s.hist.exemplars == nil || s.hist.exemplars[i] == nil {
+						return ""
+					}
+					return s.hist.exemplars[i].String()
+				}
+
+				for i, bound := range s.hist.buckets {
+					le := []labelPair{{name: "le", value: formatValue(bound)}}
+					fmt.Fprintf(w, "%s_bucket%s %d%s\n", name, formatLabels(snap.labels, s.labels, le), s.hist.counts[i], exemplar(i))
+				}
+				le := []labelPair{{name: "le", value: "+Inf"}}
+				fmt.Fprintf(w, "%s_bucket%s %d%s\n", name, formatLabels(snap.labels, s.labels, le), s.hist.count, exemplar(len(s.hist.buckets)))
+				fmt.Fprintf(w, "%s_sum%s %s\n", name, formatLabels(snap.labels, s.labels), formatValue(s.hist.sum))
+				fmt.Fprintf(w, "%s_count%s %d\n", name, formatLabels(snap.labels, s.labels), s.hist.count)
+			case f.kind == kindCounter:
+				exemplar := ""
+				if s.exemplar != nil {
+					exemplar = s.exemplar.String()
+				}
+				fmt.Fprintf(w, "%s_total%s %s%s\n", name, formatLabels(snap.labels, s.labels), formatValue(s.value), exemplar)
+			default:
+				fmt.Fprintf(w, "%s%s %s\n", name, formatLabels(snap.labels, s.labels), formatValue(s.value))
+			}
+		}
+	}
+
+	io.WriteString(w, "# EOF\n")
+}
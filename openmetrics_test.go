@@ -0,0 +1,89 @@
+package metricer
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"go.melnyk.org/mlog/testlog"
+)
+
+func TestServerMetricsValuesOpenMetrics(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewCounter("requests_total", "requests help").IncWithExemplar(1, map[string]string{"trace_id": "abc"})
+	mhost.NewCounter("errors", "errors\nhelp").Inc(2)
+	mhost.registerHistogram("latency", "latency help", []float64{1}).ObserveWithExemplar(0.5, map[string]string{"trace_id": "def"})
+
+	req := httptest.NewRequest("GET", "http://test/metrics/values", nil)
+	req.Header.Set("Accept", "application/openmetrics-text;version=1.0.0")
+	w := httptest.NewRecorder()
+	mhost.metricsValues(w, req)
+
+	resp := w.Result()
+	if resp.Header.Get("Content-Type") != contenttypeOpenMetrics {
+		t.Errorf("Expected: %s, but got %s", contenttypeOpenMetrics, resp.Header.Get("Content-Type"))
+	}
+
+	data, _ := ioutil.ReadAll(resp.Body)
+	body := string(data)
+	hostlabel := `_os="` + goos + `"`
+
+	tests := []string{
+		"# TYPE requests counter\n",
+		`requests_total{` + hostlabel + `} 1 # {trace_id="abc"} 1 \d+\.\d{3}` + "\n",
+		"# TYPE errors counter\n# HELP errors errors\\\\nhelp\n",
+		`errors_total{` + hostlabel + `} 2` + "\n",
+		`latency_bucket{` + hostlabel + `,le="1"} 1 # {trace_id="def"} 0.5 \d+\.\d{3}` + "\n",
+		`latency_bucket{` + hostlabel + `,le="\+Inf"} 1` + "\n",
+		"# EOF\n$",
+	}
+
+	for i, v := range tests {
+		if !regexp.MustCompile(v).MatchString(body) {
+			t.Errorf("Expected (%d): %s, but got %s", i, v, body)
+		}
+	}
+	if strings.Count(body, "# EOF") != 1 {
+		t.Errorf("Expected: one EOF marker, but got %s", body)
+	}
+}
+
+func TestServerMetricsValuesPrometheusAccept(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewCounter("requests", "requests help").Inc(1)
+
+	tests := []struct {
+		accept      string
+		url         string
+		contenttype string
+		name        string
+	}{
+		// default Accept header of Prometheus scrapes
+		{"application/openmetrics-text;version=1.0.0,application/openmetrics-text;version=0.0.1;q=0.75,text/plain;version=0.0.4;q=0.5,*/*;q=0.1",
+			"http://test/metrics/values", contenttypeText, "requests{"},
+		{"application/openmetrics-text;version=1.0.0", "http://test/metrics/values", contenttypeOpenMetrics, "requests_total{"},
+		{"", "http://test/metrics/values?format=openmetrics", contenttypeOpenMetrics, "requests_total{"},
+	}
+
+	for i, v := range tests {
+		req := httptest.NewRequest("GET", v.url, nil)
+		req.Header.Set("Accept", v.accept)
+		w := httptest.NewRecorder()
+		mhost.metricsValues(w, req)
+
+		resp := w.Result()
+		if resp.Header.Get("Content-Type") != v.contenttype {
+			t.Errorf("Expected (%d): %s, but got %s", i, v.contenttype, resp.Header.Get("Content-Type"))
+		}
+
+		data, _ := ioutil.ReadAll(resp.Body)
+		if !strings.Contains(string(data), "\n"+v.name) {
+			t.Errorf("Expected (%d): %s, but got %s", i, v.name, data)
+		}
+		if v.contenttype == contenttypeText && strings.Contains(string(data), "_total") {
+			t.Errorf("Expected (%d): counter names without suffix, but got %s", i, data)
+		}
+	}
+}
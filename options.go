@@ -0,0 +1,25 @@
+package metricer
+
+// MetricOption provides optional settings for metrics
+type MetricOption func(*metricOptions)
+
+type metricOptions struct {
+	metadata
+	persistent bool
+}
+
+func applyMetricOptions(options []MetricOption) metricOptions {
+	var o metricOptions
+	for _, option := range options {
+		option(&o)
+	}
+	return o
+}
+
+// Persistent enables saving of counter or gauge value into checkpoint file (see Config.Checkpoint),
+// the saved value is restored on registration of metric with the same name
+func Persistent() MetricOption {
+	return func(o *metricOptions) {
+		o.persistent = true
+	}
+}
@@ -0,0 +1,335 @@
+package metricer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// OtlpConfig represents configuration of OTLP/HTTP exporter
+type OtlpConfig struct {
+	// URL of OTLP/HTTP metrics endpoint
+	URL string `json:"url,omitempty" yaml:"url"`
+
+	// Headers added to every request, e.g. authorization
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers"`
+
+	// Interval between pushes
+	Interval time.Duration `json:"interval,omitempty" yaml:"interval"`
+
+	// Delta enables delta temporality for counters and histograms instead of cumulative one
+	Delta bool `json:"delta,omitempty" yaml:"delta"`
+
+	// Gzip enables compression of request body
+	Gzip bool `json:"gzip,omitempty" yaml:"gzip"`
+
+	// Retries is number of retries for failed requests
+	Retries int `json:"retries,omitempty" yaml:"retries"`
+
+	// Timeout of one request
+	Timeout time.Duration `json:"timeout,omitempty" yaml:"timeout"`
+}
+
+// Validate checks OTLP config structure
+func (config *OtlpConfig) Validate() error {
+	if config.URL == "" {
+		config.URL = defaultOtlpURL
+	}
+	if config.Interval <= 0 {
+		config.Interval = defaultPushInterval
+	}
+	if config.Retries < 0 {
+		config.Retries = 0
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultPushTimeout
+	}
+	return nil
+}
+
+// Aggregation temporality of OTLP data points
+const (
+	otlpDelta      = 1
+	otlpCumulative = 2
+)
+
+type otlpKeyValue struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+type otlpDataPoint struct {
+	Attributes     []otlpKeyValue `json:"attributes,omitempty"`
+	StartTime      string         `json:"startTimeUnixNano,omitempty"`
+	Time           string         `json:"timeUnixNano"`
+	AsDouble       *otlpDouble    `json:"asDouble,omitempty"`
+	Count          string         `json:"count,omitempty"`
+	Sum            *otlpDouble    `json:"sum,omitempty"`
+	BucketCounts   []string       `json:"bucketCounts,omitempty"`
+	ExplicitBounds []float64      `json:"explicitBounds,omitempty"`
+}
+
+type otlpData struct {
+	DataPoints  []otlpDataPoint `json:"dataPoints"`
+	Temporality int             `json:"aggregationTemporality,omitempty"`
+	IsMonotonic bool            `json:"isMonotonic,omitempty"`
+}
+
+type otlpMetric struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Gauge       *otlpData `json:"gauge,omitempty"`
+	Sum         *otlpData `json:"sum,omitempty"`
+	Histogram   *otlpData `json:"histogram,omitempty"`
+}
+
+type otlpScopeMetrics struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	} `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+// otlpDouble encodes non-finite values as strings NaN, Infinity and -Infinity of protobuf JSON mapping
+type otlpDouble float64
+
+func (v otlpDouble) MarshalJSON() ([]byte, error) {
+	f := float64(v)
+	switch {
+	case math.IsNaN(f):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(f, 1):
+		return []byte(`"Infinity"`), nil
+	case math.IsInf(f, -1):
+		return []byte(`"-Infinity"`), nil
+	}
+	return json.Marshal(f)
+}
+
+type otlpRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpPrevious struct {
+	time   time.Time
+	value  float64
+	counts []uint64 // histogram buckets, not cumulative
+	count  uint64
+	sum    float64
+}
+
+type otlp struct {
+	config   OtlpConfig
+	client   *http.Client
+	previous map[string]otlpPrevious // values of the previous push for delta temporality
+}
+
+func newOtlp(config OtlpConfig) *otlp {
+	return &otlp{
+		config:   config,
+		client:   &http.Client{Timeout: config.Timeout},
+		previous: make(map[string]otlpPrevious),
+	}
+}
+
+func (exporter *otlp) name() string {
+	return "otlp"
+}
+
+func (exporter *otlp) interval() time.Duration {
+	return exporter.config.Interval
+}
+
+func (exporter *otlp) close() error {
+	exporter.client.CloseIdleConnections()
+	return nil
+}
+
+func (exporter *otlp) push(snap *snapshot) error {
+	request, baseline := exporter.request(snap)
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	if exporter.config.Gzip {
+		var b bytes.Buffer
+		zw := gzip.NewWriter(&b)
+		zw.Write(body)
+		zw.Close()
+		body = b.Bytes()
+	}
+
+	err = sendWithRetries(exporter.client, exporter.config.Retries, func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, exporter.config.URL, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", acceptJSON)
+		if exporter.config.Gzip {
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+		for k, v := range exporter.config.Headers {
+			req.Header.Set(k, v)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// deltas of the failed request are included into the next one
+	exporter.commit(baseline)
+	return nil
+}
+
+// histogramReset reports whether bucket counts of histogram are decreased since the previous push
+func (previous otlpPrevious) histogramReset(counts []uint64, count uint64) bool {
+	if len(previous.counts) != len(counts) || count < previous.count {
+		return true
+	}
+	for i, v := range counts {
+		if v < previous.counts[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// commit saves baseline of delta temporality
+func (exporter *otlp) commit(baseline map[string]otlpPrevious) {
+	for k, v := range baseline {
+		exporter.previous[k] = v
+	}
+}
+
+func otlpAttributes(labels []labelPair) []otlpKeyValue {
+	attributes := make([]otlpKeyValue, len(labels))
+	for i, v := range labels {
+		attributes[i].Key = v.name
+		attributes[i].Value.StringValue = v.value
+	}
+	return attributes
+}
+
+func otlpTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// request converts snapshot into OTLP metrics request, host labels are used as resource attributes.
+// Values of the snapshot are returned as baseline of delta temporality, it is saved after successful send only.
+func (exporter *otlp) request(snap *snapshot) (*otlpRequest, map[string]otlpPrevious) {
+	temporality := otlpCumulative
+	if exporter.config.Delta {
+		temporality = otlpDelta
+	}
+
+	baseline := make(map[string]otlpPrevious)
+	metrics := make([]otlpMetric, 0, len(snap.families))
+	for _, f := range snap.families {
+		data := &otlpData{DataPoints: make([]otlpDataPoint, 0, len(f.series))}
+		metric := otlpMetric{Name: f.name, Description: f.help}
+
+		switch f.kind {
+		case kindCounter:
+			data.Temporality = temporality
+			data.IsMonotonic = true
+			metric.Sum = data
+		case kindHistogram:
+			data.Temporality = temporality
+			metric.Histogram = data
+		default:
+			metric.Gauge = data
+		}
+
+		for _, s := range f.series {
+			key := f.name + formatLabels(s.labels)
+			point := otlpDataPoint{
+				Attributes: otlpAttributes(s.labels),
+				Time:       otlpTime(snap.time),
+			}
+
+			// start of the aggregation interval, it is the previous push for delta temporality
+			start := snap.started
+			previous, seen := exporter.previous[key]
+
+			switch {
+			case s.hist != nil:
+				counts := make([]uint64, len(s.hist.counts)+1)
+				var last uint64
+				for i, v := range s.hist.counts {
+					counts[i] = v - last
+					last = v
+				}
+				counts[len(counts)-1] = s.hist.count - last
+
+				current := otlpPrevious{time: snap.time, counts: append([]uint64(nil), counts...), count: s.hist.count, sum: s.hist.sum}
+				count, sum := s.hist.count, s.hist.sum
+				if exporter.config.Delta {
+					// decreased counts mean reset of the metric (e.g. it is registered again), full values are sent
+					if seen && !previous.histogramReset(counts, count) {
+						for i := range counts {
+							counts[i] -= previous.counts[i]
+						}
+						count -= previous.count
+						sum -= previous.sum
+						start = previous.time
+					}
+					baseline[key] = current
+				}
+
+				point.StartTime = otlpTime(start)
+				point.Count = strconv.FormatUint(count, 10)
+				point.Sum = (*otlpDouble)(&sum)
+				point.BucketCounts = make([]string, len(counts))
+				for i, v := range counts {
+					point.BucketCounts[i] = strconv.FormatUint(v, 10)
+				}
+				point.ExplicitBounds = s.hist.buckets
+				if point.ExplicitBounds == nil {
+					point.ExplicitBounds = []float64{}
+				}
+			case f.kind == kindCounter:
+				value := s.value
+				if exporter.config.Delta {
+					// decreased value means reset of the counter, full value is sent
+					if seen && value >= previous.value {
+						value -= previous.value
+						start = previous.time
+					}
+					baseline[key] = otlpPrevious{time: snap.time, value: s.value}
+				}
+				point.StartTime = otlpTime(start)
+				point.AsDouble = (*otlpDouble)(&value)
+			default:
+				value := s.value
+				point.AsDouble = (*otlpDouble)(&value)
+			}
+
+			data.DataPoints = append(data.DataPoints, point)
+		}
+
+		metrics = append(metrics, metric)
+	}
+
+	scope := otlpScopeMetrics{Metrics: metrics}
+	scope.Scope.Name = otlpScope
+
+	resource := otlpResourceMetrics{ScopeMetrics: []otlpScopeMetrics{scope}}
+	resource.Resource.Attributes = otlpAttributes(snap.labels)
+
+	return &otlpRequest{ResourceMetrics: []otlpResourceMetrics{resource}}, baseline
+}
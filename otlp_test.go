@@ -0,0 +1,237 @@
+package metricer
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.melnyk.org/mlog/testlog"
+)
+
+func findOtlpMetric(request *otlpRequest, name string) *otlpMetric {
+	for i, v := range request.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		if v.Name == name {
+			return &request.ResourceMetrics[0].ScopeMetrics[0].Metrics[i]
+		}
+	}
+	return nil
+}
+
+func TestOtlpRequestCumulative(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	counter := mhost.NewCounter("counter", "counter help")
+	histogram := mhost.registerHistogram("histogram", "histogram help", []float64{1})
+
+	exporter := newOtlp(OtlpConfig{})
+	counter.Inc(5)
+	histogram.Observe(0.5)
+	histogram.Observe(2)
+	exporter.request(mhost.collect())
+	counter.Inc(5)
+	request, _ := exporter.request(mhost.collect())
+
+	resource := request.ResourceMetrics[0].Resource.Attributes
+	if len(resource) != 1 || resource[0].Key != rtmetricos || resource[0].Value.StringValue != goos {
+		t.Errorf("Expected: host labels as resource attributes, but got %v", resource)
+	}
+
+	metric := findOtlpMetric(request, "counter")
+	if metric == nil || metric.Sum == nil {
+		t.Fatal("Expected: counter as sum metric")
+	}
+	if metric.Sum.Temporality != otlpCumulative || !metric.Sum.IsMonotonic {
+		t.Errorf("Expected: cumulative monotonic sum, but got %v", metric.Sum)
+	}
+	if v := *metric.Sum.DataPoints[0].AsDouble; v != 10 {
+		t.Errorf("Expected: 10, but got %f", v)
+	}
+
+	metric = findOtlpMetric(request, "histogram")
+	if metric == nil || metric.Histogram == nil {
+		t.Fatal("Expected: histogram metric")
+	}
+	point := metric.Histogram.DataPoints[0]
+	if point.Count != "2" || *point.Sum != 2.5 || len(point.BucketCounts) != 2 || point.BucketCounts[0] != "1" || point.BucketCounts[1] != "1" {
+		t.Errorf("Expected: histogram data point, but got %v", point)
+	}
+
+	metric = findOtlpMetric(request, rtmetricnumcpu)
+	if metric == nil || metric.Gauge == nil {
+		t.Fatal("Expected: gauge metric")
+	}
+}
+
+func TestOtlpRequestDelta(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	counter := mhost.NewCounter("counter", "counter help")
+	histogram := mhost.registerHistogram("histogram", "histogram help", []float64{1})
+
+	exporter := newOtlp(OtlpConfig{Delta: true})
+	counter.Inc(5)
+	histogram.Observe(0.5)
+	_, baseline := exporter.request(mhost.collect())
+	exporter.commit(baseline)
+	counter.Inc(3)
+	histogram.Observe(2)
+	request, _ := exporter.request(mhost.collect())
+
+	metric := findOtlpMetric(request, "counter")
+	if metric.Sum.Temporality != otlpDelta {
+		t.Errorf("Expected: delta temporality, but got %d", metric.Sum.Temporality)
+	}
+	if v := *metric.Sum.DataPoints[0].AsDouble; v != 3 {
+		t.Errorf("Expected: 3, but got %f", v)
+	}
+
+	point := findOtlpMetric(request, "histogram").Histogram.DataPoints[0]
+	if point.Count != "1" || *point.Sum != 2 || point.BucketCounts[0] != "0" || point.BucketCounts[1] != "1" {
+		t.Errorf("Expected: delta histogram data point, but got %v", point)
+	}
+}
+
+func TestOtlpRequestDeltaReset(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewCounter("counter", "counter help").Inc(5)
+	histogram := mhost.registerHistogram("histogram", "histogram help", []float64{1})
+	histogram.Observe(0.5)
+	histogram.Observe(2)
+
+	exporter := newOtlp(OtlpConfig{Delta: true})
+	_, baseline := exporter.request(mhost.collect())
+	exporter.commit(baseline)
+
+	// metrics with the same names are registered again with lower values
+	mhost = NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewCounter("counter", "counter help").Inc(2)
+	mhost.registerHistogram("histogram", "histogram help", []float64{1}).Observe(0.5)
+	snap := mhost.collect()
+	request, _ := exporter.request(snap)
+
+	point := findOtlpMetric(request, "counter").Sum.DataPoints[0]
+	if *point.AsDouble != 2 || point.StartTime != otlpTime(snap.started) {
+		t.Errorf("Expected: full counter value since start, but got %v", point)
+	}
+
+	point = findOtlpMetric(request, "histogram").Histogram.DataPoints[0]
+	if point.Count != "1" || *point.Sum != 0.5 || point.BucketCounts[0] != "1" || point.BucketCounts[1] != "0" || point.StartTime != otlpTime(snap.started) {
+		t.Errorf("Expected: full histogram values since start, but got %v", point)
+	}
+}
+
+func TestOtlpPush(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the first request fails to validate retries
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		if r.URL.Path != "/v1/metrics" || r.Header.Get("X-Test") != "test" {
+			t.Errorf("Expected: request to /v1/metrics with headers, but got %s %v", r.URL.Path, r.Header)
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Fatalf("Expected: gzip body, but got %s", err.Error())
+		}
+		var request otlpRequest
+		if err := json.NewDecoder(zr).Decode(&request); err != nil {
+			t.Errorf("Expected: OTLP JSON payload, but got %s", err.Error())
+		}
+		if findOtlpMetric(&request, "counter") == nil {
+			t.Error("Expected: counter in payload")
+		}
+	}))
+	defer server.Close()
+
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewCounter("counter", "counter help")
+
+	exporter := newOtlp(OtlpConfig{URL: server.URL + "/v1/metrics", Headers: map[string]string{"X-Test": "test"}, Gzip: true, Retries: 1, Timeout: time.Second})
+	defer exporter.close()
+
+	if err := exporter.push(mhost.collect()); err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+	if calls != 2 {
+		t.Errorf("Expected: 2 calls, but got %d", calls)
+	}
+}
+
+func TestOtlpPushFailed(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	exporter := newOtlp(OtlpConfig{URL: server.URL, Retries: 3, Timeout: time.Second})
+	defer exporter.close()
+
+	if err := exporter.push(mhost.collect()); err == nil {
+		t.Fatal("Expected: error, but got nil")
+	}
+	if calls != 1 {
+		t.Errorf("Expected: no retries for bad request, but got %d calls", calls)
+	}
+}
+
+func TestOtlpRequestNonFinite(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.registerHistogram("latency", "latency help", []float64{1}).Observe(math.Inf(1))
+
+	exporter := newOtlp(OtlpConfig{})
+	request, _ := exporter.request(mhost.collect())
+	body, err := json.Marshal(request)
+	if err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+	if !strings.Contains(string(body), `"sum":"Infinity"`) {
+		t.Errorf("Expected: sum encoded as Infinity, but got %s", body)
+	}
+}
+
+func TestOtlpPushDeltaFailed(t *testing.T) {
+	var failed int32 = 1
+	var values []float64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&failed) != 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var request otlpRequest
+		json.NewDecoder(r.Body).Decode(&request)
+		values = append(values, float64(*findOtlpMetric(&request, "counter").Sum.DataPoints[0].AsDouble))
+	}))
+	defer server.Close()
+
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	counter := mhost.NewCounter("counter", "counter help")
+
+	exporter := newOtlp(OtlpConfig{URL: server.URL, Delta: true, Timeout: time.Second})
+	defer exporter.close()
+
+	// delta of the failed push is sent with the next one
+	counter.Inc(5)
+	if err := exporter.push(mhost.collect()); err == nil {
+		t.Fatal("Expected: error, but got nil")
+	}
+
+	atomic.StoreInt32(&failed, 0)
+	counter.Inc(3)
+	exporter.push(mhost.collect())
+	counter.Inc(2)
+	exporter.push(mhost.collect())
+
+	if len(values) != 2 || values[0] != 8 || values[1] != 2 {
+		t.Errorf("Expected: deltas [8 2], but got %v", values)
+	}
+}
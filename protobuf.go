@@ -0,0 +1,67 @@
+package metricer
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Wire types of protobuf encoding
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+)
+
+// protoBuffer is minimal protobuf encoder, fields are appended in the order of calls
+type protoBuffer []byte
+
+func (b *protoBuffer) varint(v uint64) {
+	*b = append(*b, make([]byte, binary.MaxVarintLen64)...)
+	n := binary.PutUvarint((*b)[len(*b)-binary.MaxVarintLen64:], v)
+	*b = (*b)[:len(*b)-binary.MaxVarintLen64+n]
+}
+
+func (b *protoBuffer) tag(field int, wire int) {
+	b.varint(uint64(field)<<3 | uint64(wire))
+}
+
+// uint64 appends varint field
+func (b *protoBuffer) uint64(field int, v uint64) {
+	b.tag(field, protoVarint)
+	b.varint(v)
+}
+
+// int64 appends varint field, negative values take 10 bytes
+func (b *protoBuffer) int64(field int, v int64) {
+	b.uint64(field, uint64(v))
+}
+
+// sint64 appends zigzag encoded varint field
+func (b *protoBuffer) sint64(field int, v int64) {
+	b.uint64(field, uint64(v<<1)^uint64(v>>63))
+}
+
+func (b *protoBuffer) double(field int, v float64) {
+	b.tag(field, protoFixed64)
+	*b = append(*b, 0, 0, 0, 0, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint64((*b)[len(*b)-8:], math.Float64bits(v))
+}
+
+func (b *protoBuffer) bytes(field int, v []byte) {
+	b.tag(field, protoBytes)
+	b.varint(uint64(len(v)))
+	*b = append(*b, v...)
+}
+
+func (b *protoBuffer) string(field int, v string) {
+	b.tag(field, protoBytes)
+	b.varint(uint64(len(v)))
+	*b = append(*b, v...)
+}
+
+// message appends embedded message built by encode function
+func (b *protoBuffer) message(field int, encode func(*protoBuffer)) {
+	var m protoBuffer
+	encode(&m)
+	b.bytes(field, m)
+}
@@ -0,0 +1,93 @@
+package metricer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// protoField represents decoded protobuf field, used to validate encoded messages
+type protoField struct {
+	field int
+	wire  int
+	value uint64 // varint and fixed64
+	data  []byte // length delimited
+}
+
+// protoDecode splits message into fields
+func protoDecode(t *testing.T, b []byte) []protoField {
+	var fields []protoField
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			t.Fatalf("Expected: valid tag, but got %v", b)
+		}
+		b = b[n:]
+
+		f := protoField{field: int(key >> 3), wire: int(key & 7)}
+		switch f.wire {
+		case protoVarint:
+			f.value, n = binary.Uvarint(b)
+			if n <= 0 {
+				t.Fatalf("Expected: valid varint, but got %v", b)
+			}
+			b = b[n:]
+		case protoFixed64:
+			if len(b) < 8 {
+				t.Fatalf("Expected: 8 bytes, but got %v", b)
+			}
+			f.value = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+		case protoBytes:
+			size, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < size {
+				t.Fatalf("Expected: valid length, but got %v", b)
+			}
+			f.data = b[n : n+int(size)]
+			b = b[n+int(size):]
+		default:
+			t.Fatalf("Expected: known wire type, but got %d", f.wire)
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func TestProtoBuffer(t *testing.T) {
+	tests := []struct {
+		encode func(*protoBuffer)
+		result []byte
+	}{
+		{func(b *protoBuffer) { b.uint64(1, 150) }, []byte{0x08, 0x96, 0x01}},
+		{func(b *protoBuffer) { b.int64(2, -1) }, []byte{0x10, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}},
+		{func(b *protoBuffer) { b.sint64(1, -2) }, []byte{0x08, 0x03}},
+		{func(b *protoBuffer) { b.sint64(1, 2) }, []byte{0x08, 0x04}},
+		{func(b *protoBuffer) { b.double(1, 1) }, []byte{0x09, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f}},
+		{func(b *protoBuffer) { b.string(2, "testing") }, []byte{0x12, 0x07, 't', 'e', 's', 't', 'i', 'n', 'g'}},
+		{func(b *protoBuffer) { b.message(3, func(m *protoBuffer) { m.uint64(1, 150) }) }, []byte{0x1a, 0x03, 0x08, 0x96, 0x01}},
+	}
+
+	for i, v := range tests {
+		var b protoBuffer
+		v.encode(&b)
+		if !bytes.Equal(b, v.result) {
+			t.Errorf("Expected (%d): %v, but got %v", i, v.result, []byte(b))
+		}
+	}
+}
+
+func TestProtoDecode(t *testing.T) {
+	var b protoBuffer
+	b.string(1, "name")
+	b.double(2, 0.5)
+	b.uint64(3, 7)
+
+	fields := protoDecode(t, b)
+	if len(fields) != 3 {
+		t.Fatalf("Expected: 3 fields, but got %d", len(fields))
+	}
+	if string(fields[0].data) != "name" || math.Float64frombits(fields[1].value) != 0.5 || fields[2].value != 7 {
+		t.Errorf("Expected: decoded fields, but got %v", fields)
+	}
+}
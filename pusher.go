@@ -0,0 +1,120 @@
+package metricer
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.melnyk.org/mlog"
+)
+
+// pusher is implemented by exporters pushing metrics into external systems
+type pusher interface {
+	name() string
+	interval() time.Duration
+	push(snap *snapshot) error
+	close() error
+}
+
+// startPushers runs push loop for every configured exporter
+func (h *host) startPushers() {
+	for _, p := range h.pushers {
+		h.wg.Add(1)
+		go h.runPusher(p)
+	}
+}
+
+// runPusher pushes metrics on every interval till host is stopped, the last push is done on stop
+func (h *host) runPusher(p pusher) {
+	defer h.wg.Done()
+
+	h.log.Event(mlog.Verbose, func(e mlog.Event) {
+		e.String("msg", "Starting exporter")
+		e.String("exporter", p.name())
+	})
+
+	ticker := time.NewTicker(p.interval())
+	defer ticker.Stop()
+
+	for stop := false; !stop; {
+		select {
+		case <-ticker.C:
+		case <-h.done:
+			stop = true
+		}
+
+		h.updateRuntime()
+		if err := p.push(h.collect()); err != nil {
+			h.log.Event(mlog.Warning, func(e mlog.Event) {
+				e.String("msg", "Exporter push failed")
+				e.String("exporter", p.name())
+				e.String("err", err.Error())
+			})
+		}
+	}
+
+	if err := p.close(); err != nil {
+		h.log.Event(mlog.Warning, func(e mlog.Event) {
+			e.String("msg", "Exporter close failed")
+			e.String("exporter", p.name())
+			e.String("err", err.Error())
+		})
+	}
+
+	h.log.Event(mlog.Verbose, func(e mlog.Event) {
+		e.String("msg", "Exporter has been stopped")
+		e.String("exporter", p.name())
+	})
+}
+
+// statusError is returned for requests failed with unexpected response status
+type statusError struct {
+	status  int
+	message string
+}
+
+func (err *statusError) Error() string {
+	return fmt.Sprintf("Request failed with status %d: %s", err.status, err.message)
+}
+
+// retryable reports whether the request can succeed when it is sent again
+func (err *statusError) retryable() bool {
+	return err.status == http.StatusTooManyRequests || err.status >= 500
+}
+
+// sendWithRetries sends request created by build function, the request is retried with exponential
+// backoff on network errors, 429 and 5xx responses
+func sendWithRetries(client *http.Client, retries int, build func() (*http.Request, error)) error {
+	backoff := minRetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		req, err := build()
+		if err != nil {
+			return err
+		}
+
+		retry := true
+		resp, err := client.Do(req)
+		if err == nil {
+			message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+			resp.Body.Close()
+
+			if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+				return nil
+			}
+			serr := &statusError{status: resp.StatusCode, message: strings.TrimSpace(string(message))}
+			retry = serr.retryable()
+			err = serr
+		}
+
+		if !retry || attempt >= retries {
+			return err
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
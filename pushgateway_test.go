@@ -0,0 +1,117 @@
+package metricer
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.melnyk.org/mlog/testlog"
+)
+
+func TestPushgatewayURL(t *testing.T) {
+	tests := []struct {
+		gateway  string
+		job      string
+		grouping map[string]string
+		url      string
+		err      error
+	}{
+		{"http://gw:9091", "batch", nil, "http://gw:9091/metrics/job/batch", nil},
+		{"http://gw:9091/", "batch", map[string]string{"zone": "eu", "instance": "a b"}, "http://gw:9091/metrics/job/batch/instance/a%20b/zone/eu", nil},
+		{"http://gw:9091/prefix", "batch", map[string]string{"path": "/var/tmp"}, "http://gw:9091/prefix/metrics/job/batch/path@base64/L3Zhci90bXA", nil},
+		{"http://gw:9091", "a/b", map[string]string{"empty": ""}, "http://gw:9091/metrics/job@base64/YS9i/empty@base64/=", nil},
+		{"http://gw:9091", "", nil, "", errPushJobEmpty},
+		{"http://gw:9091", "batch", map[string]string{"job": "other"}, "", errPushLabelInvalid},
+		{"http://gw:9091", "batch", map[string]string{"1abc": "other"}, "", errPushLabelInvalid},
+	}
+
+	for i, v := range tests {
+		location, err := pushgatewayURL(v.gateway, v.job, v.grouping)
+		if err != v.err {
+			t.Errorf("Expected (%d): %v, but got %v", i, v.err, err)
+			continue
+		}
+		if err == nil && location.String() != v.url {
+			t.Errorf("Expected (%d): %s, but got %s", i, v.url, location.String())
+		}
+	}
+}
+
+func TestPushgateway(t *testing.T) {
+	var method, path, body, contenttype, user, password string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		method, path, body = r.Method, r.URL.EscapedPath(), string(data)
+		contenttype = r.Header.Get("Content-Type")
+		user, password, _ = r.BasicAuth()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	mhost := NewHost(nil, testlog.NewLogbook())
+	counter := mhost.NewCounter("processed", "processed help")
+	counter.Inc(7)
+
+	gateway := strings.Replace(server.URL, "http://", "http://user:secret@", 1)
+	grouping := map[string]string{"instance": "cron"}
+
+	tests := []struct {
+		method string
+		call   func(context.Context, string, string, map[string]string) error
+	}{
+		{http.MethodPut, mhost.Push},
+		{http.MethodPost, mhost.PushAdd},
+		{http.MethodDelete, mhost.Delete},
+	}
+
+	for i, v := range tests {
+		if err := v.call(context.Background(), gateway, "batch", grouping); err != nil {
+			t.Fatalf("Expected (%d): no errors, but got %s", i, err.Error())
+		}
+		if method != v.method {
+			t.Errorf("Expected (%d): %s, but got %s", i, v.method, method)
+		}
+		if path != "/metrics/job/batch/instance/cron" {
+			t.Errorf("Expected (%d): grouping key path, but got %s", i, path)
+		}
+		if user != "user" || password != "secret" {
+			t.Errorf("Expected (%d): basic auth, but got %s:%s", i, user, password)
+		}
+
+		if v.method == http.MethodDelete {
+			if body != "" {
+				t.Errorf("Expected (%d): empty body, but got %s", i, body)
+			}
+			continue
+		}
+		if contenttype != contenttypePush {
+			t.Errorf("Expected (%d): %s, but got %s", i, contenttypePush, contenttype)
+		}
+		if !strings.Contains(body, "# TYPE processed counter\n") || !strings.Contains(body, `processed{_os="`+goos+`"} 7`) {
+			t.Errorf("Expected (%d): metrics in text format, but got %s", i, body)
+		}
+	}
+}
+
+func TestPushgatewayFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "pushed metrics are invalid", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	mhost := NewHost(nil, testlog.NewLogbook())
+
+	err := mhost.Push(context.Background(), server.URL, "batch", nil)
+	if err == nil || !strings.Contains(err.Error(), "400") || !strings.Contains(err.Error(), "pushed metrics are invalid") {
+		t.Errorf("Expected: error with status and message, but got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := mhost.Push(ctx, server.URL, "batch", nil); err == nil {
+		t.Error("Expected: error for canceled context, but got nil")
+	}
+}
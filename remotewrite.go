@@ -0,0 +1,202 @@
+package metricer
+
+import (
+	"bytes"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// RemoteWriteConfig represents configuration of Prometheus remote write exporter
+type RemoteWriteConfig struct {
+	// URL of remote write endpoint
+	URL string `json:"url,omitempty" yaml:"url"`
+
+	// Username and Password enable basic auth
+	Username string `json:"username,omitempty" yaml:"username"`
+	Password string `json:"password,omitempty" yaml:"password"`
+
+	// Headers added to every request, e.g. authorization
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers"`
+
+	// Interval between snapshots
+	Interval time.Duration `json:"interval,omitempty" yaml:"interval"`
+
+	// Queue is max number of samples kept in memory while endpoint is not available
+	Queue int `json:"queue,omitempty" yaml:"queue"`
+
+	// Batch is max number of samples sent in one request
+	Batch int `json:"batch,omitempty" yaml:"batch"`
+
+	// Retries is number of retries for failed requests, negative value disables retries
+	Retries int `json:"retries,omitempty" yaml:"retries"`
+
+	// Timeout of one request
+	Timeout time.Duration `json:"timeout,omitempty" yaml:"timeout"`
+}
+
+// Validate checks remote write config structure
+func (config *RemoteWriteConfig) Validate() error {
+	if config.URL == "" {
+		config.URL = defaultRemoteWriteURL
+	}
+	if config.Interval <= 0 {
+		config.Interval = defaultPushInterval
+	}
+	if config.Queue <= 0 {
+		config.Queue = defaultRemoteWriteQueue
+	}
+	if config.Batch <= 0 {
+		config.Batch = defaultRemoteWriteBatch
+	}
+	if config.Retries == 0 {
+		config.Retries = defaultPushRetries
+	}
+	if config.Retries < 0 {
+		config.Retries = 0
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultPushTimeout
+	}
+	return nil
+}
+
+// remoteSample represents one sample of time series, labels are sorted by name
+type remoteSample struct {
+	labels    []labelPair
+	value     float64
+	timestamp int64 // milliseconds
+}
+
+type remoteWrite struct {
+	config  RemoteWriteConfig
+	client  *http.Client
+	queue   []remoteSample // samples which are not sent yet, the oldest first
+	backoff time.Duration
+	next    time.Time // time of the next send attempt
+}
+
+func newRemoteWrite(config RemoteWriteConfig) *remoteWrite {
+	return &remoteWrite{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+	}
+}
+
+func (exporter *remoteWrite) name() string {
+	return "remote_write"
+}
+
+func (exporter *remoteWrite) interval() time.Duration {
+	return exporter.config.Interval
+}
+
+func (exporter *remoteWrite) close() error {
+	exporter.client.CloseIdleConnections()
+	return nil
+}
+
+func (exporter *remoteWrite) push(snap *snapshot) error {
+	exporter.enqueue(snap)
+
+	if time.Now().Before(exporter.next) {
+		return nil
+	}
+
+	if err := exporter.send(); err != nil {
+		// exponential backoff for the next attempts
+		exporter.backoff *= 2
+		if exporter.backoff < time.Second {
+			exporter.backoff = time.Second
+		}
+		if exporter.backoff > maxPushBackoff {
+			exporter.backoff = maxPushBackoff
+		}
+		exporter.next = time.Now().Add(exporter.backoff)
+		return err
+	}
+
+	exporter.backoff = 0
+	return nil
+}
+
+// enqueue adds samples of the snapshot to the queue, the oldest samples are dropped on overflow
+func (exporter *remoteWrite) enqueue(snap *snapshot) {
+	timestamp := snap.time.UnixNano() / int64(time.Millisecond)
+
+	snap.samples(func(name string, labels []labelPair, value float64) {
+		all := make([]labelPair, 0, len(snap.labels)+len(labels)+1)
+		all = append(all, labelPair{name: "__name__", value: name})
+		all = append(all, snap.labels...)
+		all = append(all, labels...)
+		sort.SliceStable(all, func(i, j int) bool {
+			return all[i].name < all[j].name
+		})
+		exporter.queue = append(exporter.queue, remoteSample{labels: all, value: value, timestamp: timestamp})
+	})
+
+	if overflow := len(exporter.queue) - exporter.config.Queue; overflow > 0 {
+		exporter.queue = append(exporter.queue[:0], exporter.queue[overflow:]...)
+	}
+}
+
+// send writes queued samples in batches, batches rejected by the endpoint are dropped
+func (exporter *remoteWrite) send() error {
+	var lasterr error
+
+	for len(exporter.queue) > 0 {
+		batch := exporter.queue
+		if len(batch) > exporter.config.Batch {
+			batch = batch[:exporter.config.Batch]
+		}
+
+		body := snappyEncode(remoteWriteRequest(batch))
+		err := sendWithRetries(exporter.client, exporter.config.Retries, func() (*http.Request, error) {
+			req, err := http.NewRequest(http.MethodPost, exporter.config.URL, bytes.NewReader(body))
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Content-Type", contenttypeProtobuf)
+			req.Header.Set("Content-Encoding", "snappy")
+			req.Header.Set("X-Prometheus-Remote-Write-Version", remoteWriteVersion)
+			if exporter.config.Username != "" {
+				req.SetBasicAuth(exporter.config.Username, exporter.config.Password)
+			}
+			for k, v := range exporter.config.Headers {
+				req.Header.Set(k, v)
+			}
+			return req, nil
+		})
+
+		if serr, ok := err.(*statusError); ok && !serr.retryable() {
+			// the batch will be never accepted
+			lasterr = err
+		} else if err != nil {
+			return err
+		}
+
+		exporter.queue = append(exporter.queue[:0], exporter.queue[len(batch):]...)
+	}
+
+	return lasterr
+}
+
+// remoteWriteRequest encodes samples as prometheus.WriteRequest protobuf message
+func remoteWriteRequest(samples []remoteSample) []byte {
+	var b protoBuffer
+	for _, sample := range samples {
+		b.message(1, func(ts *protoBuffer) { // TimeSeries
+			for _, v := range sample.labels {
+				ts.message(1, func(label *protoBuffer) { // Label
+					label.string(1, v.name)
+					label.string(2, v.value)
+				})
+			}
+			ts.message(2, func(s *protoBuffer) { // Sample
+				s.double(1, sample.value)
+				s.int64(2, sample.timestamp)
+			})
+		})
+	}
+	return b
+}
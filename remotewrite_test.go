@@ -0,0 +1,177 @@
+package metricer
+
+import (
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.melnyk.org/mlog/testlog"
+)
+
+// decodeWriteRequest decodes WriteRequest into the list of samples
+func decodeWriteRequest(t *testing.T, body []byte) []remoteSample {
+	var samples []remoteSample
+	for _, ts := range protoDecode(t, snappyDecode(t, body)) {
+		var sample remoteSample
+		for _, f := range protoDecode(t, ts.data) {
+			switch f.field {
+			case 1:
+				label := protoDecode(t, f.data)
+				sample.labels = append(sample.labels, labelPair{name: string(label[0].data), value: string(label[1].data)})
+			case 2:
+				s := protoDecode(t, f.data)
+				sample.value = math.Float64frombits(s[0].value)
+				sample.timestamp = int64(s[1].value)
+			}
+		}
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+func TestRemoteWriteEnqueue(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewCounter("counter", "counter help").Inc(3)
+	mhost.registerHistogram("histogram", "histogram help", []float64{1}).Observe(2)
+
+	config := RemoteWriteConfig{}
+	config.Validate()
+	exporter := newRemoteWrite(config)
+	snap := mhost.collect()
+	exporter.enqueue(snap)
+
+	found := 0
+	for _, v := range exporter.queue {
+		if v.timestamp != snap.time.UnixNano()/int64(time.Millisecond) {
+			t.Errorf("Expected: snapshot timestamp, but got %d", v.timestamp)
+		}
+		for i := 1; i < len(v.labels); i++ {
+			if v.labels[i-1].name > v.labels[i].name {
+				t.Errorf("Expected: sorted labels, but got %v", v.labels)
+			}
+		}
+
+		key := formatLabels(v.labels)
+		switch key {
+		case `{__name__="counter",_os="` + goos + `"}`:
+			found++
+			if v.value != 3 {
+				t.Errorf("Expected: 3, but got %f", v.value)
+			}
+		case `{__name__="histogram_bucket",_os="` + goos + `",le="1"}`:
+			found++
+			if v.value != 0 {
+				t.Errorf("Expected: 0, but got %f", v.value)
+			}
+		case `{__name__="histogram_bucket",_os="` + goos + `",le="+Inf"}`, `{__name__="histogram_count",_os="` + goos + `"}`:
+			found++
+			if v.value != 1 {
+				t.Errorf("Expected: 1, but got %f", v.value)
+			}
+		}
+	}
+	if found != 4 {
+		t.Errorf("Expected: 4 samples, but got %d", found)
+	}
+
+	// overflow drops the oldest samples
+	exporter.config.Queue = len(exporter.queue) + 1
+	exporter.enqueue(mhost.collect())
+	if len(exporter.queue) != exporter.config.Queue {
+		t.Errorf("Expected: %d, but got %d", exporter.config.Queue, len(exporter.queue))
+	}
+}
+
+func TestRemoteWritePush(t *testing.T) {
+	var calls int32
+	var samples []remoteSample
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the first request fails to validate retries
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		if r.Header.Get("Content-Encoding") != "snappy" || r.Header.Get("Content-Type") != contenttypeProtobuf ||
+			r.Header.Get("X-Prometheus-Remote-Write-Version") != remoteWriteVersion {
+			t.Errorf("Expected: remote write headers, but got %v", r.Header)
+		}
+		if user, password, _ := r.BasicAuth(); user != "user" || password != "secret" {
+			t.Errorf("Expected: basic auth, but got %s:%s", user, password)
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		samples = append(samples, decodeWriteRequest(t, body)...)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewCounter("counter", "counter help").Inc(3)
+
+	config := RemoteWriteConfig{URL: server.URL, Username: "user", Password: "secret", Batch: 2, Retries: 1}
+	config.Validate()
+	exporter := newRemoteWrite(config)
+	defer exporter.close()
+
+	snap := mhost.collect()
+	if err := exporter.push(snap); err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+	if len(exporter.queue) != 0 {
+		t.Errorf("Expected: empty queue, but got %d samples", len(exporter.queue))
+	}
+
+	found := false
+	for _, v := range samples {
+		if formatLabels(v.labels) == `{__name__="counter",_os="`+goos+`"}` {
+			found = true
+			if v.value != 3 || v.timestamp != snap.time.UnixNano()/int64(time.Millisecond) {
+				t.Errorf("Expected: counter sample, but got %v", v)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected: counter sample, but got %v", samples)
+	}
+}
+
+func TestRemoteWritePushFailed(t *testing.T) {
+	var status int32 = http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+	}))
+	defer server.Close()
+
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+
+	config := RemoteWriteConfig{URL: server.URL, Retries: -1}
+	config.Validate()
+	exporter := newRemoteWrite(config)
+	defer exporter.close()
+
+	// samples are kept in the queue while the endpoint is not available
+	if err := exporter.push(mhost.collect()); err == nil {
+		t.Fatal("Expected: error, but got nil")
+	}
+	queued := len(exporter.queue)
+	if queued == 0 || exporter.next.IsZero() {
+		t.Fatal("Expected: queued samples and backoff")
+	}
+	if err := exporter.push(mhost.collect()); err != nil || len(exporter.queue) != 2*queued {
+		t.Errorf("Expected: samples are queued during backoff, but got %v and %d samples", err, len(exporter.queue))
+	}
+
+	// rejected samples are dropped
+	atomic.StoreInt32(&status, http.StatusBadRequest)
+	exporter.next = time.Time{}
+	if err := exporter.push(mhost.collect()); err == nil {
+		t.Error("Expected: error, but got nil")
+	}
+	if len(exporter.queue) != 0 {
+		t.Errorf("Expected: empty queue, but got %d samples", len(exporter.queue))
+	}
+}
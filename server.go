@@ -1,30 +1,59 @@
 package metricer
 
 import (
+	"crypto/subtle"
 	"encoding/json"
+	"expvar"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
 	"net/http/pprof"
 	"runtime"
 	"strings"
-	"time"
 
 	"go.melnyk.org/mlog"
 )
 
 const (
-	pathHealthCheck   = "/health/check"
-	pathMetricsValues = "/metrics/values"
-
-	pathDebug             = "/debug/"
-	pathDebugPprof        = pathDebug + "pprof/"
-	pathDebugPprofCmdline = pathDebugPprof + "cmdline"
-	pathDebugPprofProfile = pathDebugPprof + "profile"
-	pathDebugPprofSymbol  = pathDebugPprof + "symbol"
-	pathDebugPprofTrace   = pathDebugPprof + "trace"
-	pathDebugLoggerLevels = pathDebug + "logger/levels"
+	pathHealthCheck     = "/health/check"
+	pathHealthGrpc      = "/health/grpc"
+	pathHealthGrpcWatch = pathHealthGrpc + "/watch"
+	pathMetricsValues   = "/metrics/values"
+	pathMetricsCatalog  = "/metrics/catalog"
+	pathMetricsStream   = "/metrics/stream"
+	pathMetricsHistory  = "/metrics/history"
+
+	pathDebug              = "/debug/"
+	pathDebugPprof         = pathDebug + "pprof/"
+	pathDebugPprofCmdline  = pathDebugPprof + "cmdline"
+	pathDebugPprofProfile  = pathDebugPprof + "profile"
+	pathDebugPprofSymbol   = pathDebugPprof + "symbol"
+	pathDebugPprofTrace    = pathDebugPprof + "trace"
+	pathDebugLoggerLevels  = pathDebug + "logger/levels"
+	pathDebugVars          = pathDebug + "vars"
+	pathDebugHealth        = pathDebug + "health/"
+	pathDebugHealthHistory = pathDebugHealth + "history"
+	pathDebugHealthDrain   = pathDebugHealth + "drain"
 )
 
+// enterHandler adds request to wait group of the host, requests are rejected after stop,
+// so wait group is not increased while Stop waits for it
+func (h *host) enterHandler(w http.ResponseWriter, r *http.Request) bool {
+	h.stopmu.Lock()
+	stopped := h.stopped
+	if !stopped {
+		h.wg.Add(1)
+	}
+	h.stopmu.Unlock()
+
+	if stopped {
+		h.handlerError(w, r, http.StatusServiceUnavailable, "Metricer is stopped")
+		return false
+	}
+	return true
+}
+
 func (h *host) handlerError(w http.ResponseWriter, r *http.Request, status int, message string) {
 	h.log.Event(mlog.Warning, func(e mlog.Event) {
 		e.String("msg", message)
@@ -52,7 +81,9 @@ func (h *host) handlerError(w http.ResponseWriter, r *http.Request, status int,
 }
 
 func (h *host) healthCheck(w http.ResponseWriter, r *http.Request) {
-	h.wg.Add(1)
+	if !h.enterHandler(w, r) {
+		return
+	}
 	defer h.wg.Done()
 
 	// only GET method is allowed
@@ -67,113 +98,177 @@ func (h *host) healthCheck(w http.ResponseWriter, r *http.Request) {
 	})
 
 	data := struct {
-		Status string `json:"status"`
-		Metric string `json:"metric,omitempty"`
-		Msg    string `json:"message,omitempty"`
+		Status string            `json:"status"`
+		Metric string            `json:"metric,omitempty"`
+		Msg    string            `json:"message,omitempty"`
+		Checks map[string]string `json:"checks,omitempty"`
 	}{
 		Status: "ok",
 	}
 
+	// drain mode overrides results of all health checks
+	if h.isDraining() {
+		data.Status = "failed"
+		data.Msg = "Instance is draining"
+		w.Header().Set("Content-Type", contenttypeJSON)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(data)
+		return
+	}
+
 	h.mu.RLock()
-	healthchecks := make([]Health, len(h.healthchecks))
+	healthchecks := make([]*health, len(h.healthchecks))
 	copy(healthchecks, h.healthchecks)
 	h.mu.RUnlock()
 
-iterations:
-	for _, v := range healthchecks {
-		if err := v.Check(); err != nil {
-			if err == errHealthCheckerPanic {
-				h.log.Event(mlog.Error, func(e mlog.Event) {
-					e.String("msg", "Panic in healthchecker callback")
-					e.String("metric", v.Name())
-				})
-			}
-			// update internal metrics
-			h.failedhealthchecks.Inc(1)
+	group := r.URL.Query().Get("group")
+	results := checkAll(healthchecks, group)
+	if group != "" && len(results) == 0 {
+		h.handlerError(w, r, http.StatusNotFound, "Health check group not found")
+		return
+	}
 
-			h.log.Event(mlog.Warning, func(e mlog.Event) {
-				e.String("msg", "Health check failed")
-				e.String("metric", v.Name())
-				e.String("reason", err.Error())
+	data.Checks = make(map[string]string, len(results))
+	var skipped *healthResult
+	for i, v := range results {
+		data.Checks[v.metric.Name()] = v.status.String()
+
+		if v.status == healthSkipped && skipped == nil {
+			skipped = &results[i]
+		}
+		if v.status != healthFailed {
+			continue
+		}
+
+		if v.err == errHealthCheckerPanic {
+			h.log.Event(mlog.Error, func(e mlog.Event) {
+				e.String("msg", "Panic in healthchecker callback")
+				e.String("metric", v.metric.Name())
 			})
+		}
+		// update internal metrics
+		h.failedhealthchecks.Inc(1)
+
+		h.log.Event(mlog.Warning, func(e mlog.Event) {
+			e.String("msg", "Health check failed")
+			e.String("metric", v.metric.Name())
+			e.String("reason", v.err.Error())
+		})
 
-			// build message
+		// build message for the first failed check
+		if data.Status == "ok" {
 			data.Status = "failed"
-			data.Metric = v.Name()
-			data.Msg = err.Error()
-			w.WriteHeader(http.StatusServiceUnavailable)
-			break iterations
+			data.Metric = v.metric.Name()
+			data.Msg = v.err.Error()
 		}
 	}
 
+	// failed dependency may be outside of the group, skipped check means the group cannot pass
+	if data.Status == "ok" && skipped != nil {
+		data.Status = "failed"
+		data.Metric = skipped.metric.Name()
+		data.Msg = skipped.err.Error()
+	}
+
 	w.Header().Set("Content-Type", contenttypeJSON)
+	if data.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
 	json.NewEncoder(w).Encode(data)
 }
 
 func (h *host) metricsInJSON(w http.ResponseWriter, r *http.Request) {
-	h.mu.RLock()
-	metrics := make([]interface{}, len(h.metrics))
-	copy(metrics, h.metrics)
-	h.mu.RUnlock()
+	snap := h.collect()
 
 	// Build response
 	data := make(map[string]interface{})
-	m := make(map[string]interface{})
-	for _, v := range metrics {
-		switch v := v.(type) {
-		case Counter:
-			m[v.Name()] = v.Count()
-		case Gauge:
-			m[v.Name()] = v.Value()
-		case Label:
-			data[v.Name()] = v.Value()
-		}
+	for _, v := range snap.labels {
+		data[v.name] = v.value
 	}
 
-	data["uptime"] = time.Since(h.started)
-	data["metrics"] = m
+	data["uptime"] = snap.time.Sub(h.started)
+	data["draining"] = h.isDraining()
+	data["metrics"] = jsonMetrics(snap)
 
 	json.NewEncoder(w).Encode(data)
 }
 
-func (h *host) metricsInOpenMetrics(w http.ResponseWriter, r *http.Request) {
-	h.mu.RLock()
-	metrics := make([]interface{}, len(h.metrics))
-	copy(metrics, h.metrics)
-	h.mu.RUnlock()
+// jsonFloat encodes non-finite values as strings NaN, +Inf and -Inf, they are not supported by JSON numbers
+type jsonFloat float64
 
-	// Extract labels first
-	labels := make([]string, 0)
-	for _, v := range metrics {
-		switch v := v.(type) {
-		case Label:
-			var b strings.Builder
-			fmt.Fprintf(&b, `%s="%s"`, v.Name(), v.Value())
-			labels = append(labels, b.String())
-		}
+func (v jsonFloat) MarshalJSON() ([]byte, error) {
+	f := float64(v)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return []byte(`"` + formatValue(f) + `"`), nil
 	}
-	label := strings.Join(labels, ",")
-
-	for _, v := range metrics {
-		switch v := v.(type) {
-		case Counter:
-			fmt.Fprintf(w, "# HELP %s %s\n", v.Name(), v.Help())
-			fmt.Fprintf(w, "# TYPE %s counter\n", v.Name())
-			fmt.Fprintf(w, "%s{%s} %d\n", v.Name(), label, v.Count())
-		case Gauge:
-			fmt.Fprintf(w, "# HELP %s %s\n", v.Name(), v.Help())
-			fmt.Fprintf(w, "# TYPE %s gauge\n", v.Name())
-			fmt.Fprintf(w, "%s{%s} %d\n", v.Name(), label, v.Value())
+	return json.Marshal(f)
+}
+
+// jsonMetrics builds map of metric values, series with labels are keyed by name with labels, e.g. name{a="b"}
+func jsonMetrics(snap *snapshot) map[string]interface{} {
+	m := make(map[string]interface{})
+	for _, f := range snap.families {
+		if f.name == rtuptime {
+			continue
+		}
+		for _, s := range f.series {
+			name := f.name
+			if len(s.labels) > 0 {
+				name += formatLabels(s.labels)
+			}
+
+			if s.hist == nil {
+				m[name] = jsonFloat(s.value)
+				continue
+			}
+
+			buckets := make(map[string]uint64)
+			for i, bound := range s.hist.buckets {
+				buckets[formatValue(bound)] = s.hist.counts[i]
+			}
+			buckets["+Inf"] = s.hist.count
+			m[name] = map[string]interface{}{
+				"count":   s.hist.count,
+				"sum":     jsonFloat(s.hist.sum),
+				"buckets": buckets,
+			}
 		}
 	}
+	return m
+}
+
+func (h *host) metricsInOpenMetrics(w http.ResponseWriter, r *http.Request) {
+	writeText(w, h.collect())
+}
+
+// writeText writes snapshot in the text exposition format
+func writeText(w io.Writer, snap *snapshot) {
+	for _, f := range snap.families {
+		fmt.Fprintf(w, "# HELP %s %s\n", f.name, f.help)
+		fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
 
-	fmt.Fprintf(w, "# HELP %s %s\n", rtuptime, rtuptimehelp)
-	fmt.Fprintf(w, "# TYPE %s gauge\n", rtuptime)
-	fmt.Fprintf(w, "%s{%s} %d\n", rtuptime, label, time.Since(h.started))
+		for _, s := range f.series {
+			if s.hist == nil {
+				fmt.Fprintf(w, "%s%s %s\n", f.name, formatLabels(snap.labels, s.labels), formatValue(s.value))
+				continue
+			}
+
+			for i, bound := range s.hist.buckets {
+				le := []labelPair{{name: "le", value: formatValue(bound)}}
+				fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, formatLabels(snap.labels, s.labels, le), s.hist.counts[i])
+			}
+			le := []labelPair{{name: "le", value: "+Inf"}}
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, formatLabels(snap.labels, s.labels, le), s.hist.count)
+			fmt.Fprintf(w, "%s_sum%s %s\n", f.name, formatLabels(snap.labels, s.labels), formatValue(s.hist.sum))
+			fmt.Fprintf(w, "%s_count%s %d\n", f.name, formatLabels(snap.labels, s.labels), s.hist.count)
+		}
+	}
 }
 
 func (h *host) metricsValues(w http.ResponseWriter, r *http.Request) {
-	h.wg.Add(1)
+	if !h.enterHandler(w, r) {
+		return
+	}
 	defer h.wg.Done()
 
 	// only GET method is allowed
@@ -194,19 +289,29 @@ func (h *host) metricsValues(w http.ResponseWriter, r *http.Request) {
 	})
 
 	// update runtime metrics
-	var memstats runtime.MemStats
-	runtime.ReadMemStats(&memstats)
-	h.rtmemalloc.Update(int64(memstats.Alloc))
-	h.rtgoroutines.Update(int64(runtime.NumGoroutine()))
-
-	switch {
-	case strings.Contains(accept, acceptJSON):
-		w.Header().Set("Content-Type", contenttypeJSON)
-		h.metricsInJSON(w, r)
-	default:
-		w.Header().Set("Content-Type", contenttypeText)
-		h.metricsInOpenMetrics(w, r)
-	}
+	h.updateRuntime()
+
+	format := r.URL.Query().Get("format")
+
+	h.compressed(w, r, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case format == formatInflux || strings.Contains(accept, acceptInflux):
+			w.Header().Set("Content-Type", contenttypeText)
+			h.metricsInInflux(w, r)
+		case acceptsProtobuf(accept):
+			w.Header().Set("Content-Type", contenttypeProtobufDelimited)
+			writeProtobuf(w, h.collect())
+		case acceptsOpenMetrics(accept, format):
+			w.Header().Set("Content-Type", contenttypeOpenMetrics)
+			writeOpenMetrics(w, h.collect())
+		case strings.Contains(accept, acceptJSON):
+			w.Header().Set("Content-Type", contenttypeJSON)
+			h.metricsInJSON(w, r)
+		default:
+			w.Header().Set("Content-Type", contenttypeText)
+			h.metricsInOpenMetrics(w, r)
+		}
+	})
 }
 
 func (h *host) loggerGetLevels(w http.ResponseWriter, r *http.Request) {
@@ -292,7 +397,9 @@ func (h *host) loggerPatchLevels(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *host) loggerLevels(w http.ResponseWriter, r *http.Request) {
-	h.wg.Add(1)
+	if !h.enterHandler(w, r) {
+		return
+	}
 	defer h.wg.Done()
 
 	switch r.Method {
@@ -305,12 +412,90 @@ func (h *host) loggerLevels(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (h *host) healthHistory(w http.ResponseWriter, r *http.Request) {
+	if !h.enterHandler(w, r) {
+		return
+	}
+	defer h.wg.Done()
+
+	// only GET method is allowed
+	if r.Method != http.MethodGet {
+		h.handlerError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	h.log.Event(mlog.Verbose, func(e mlog.Event) {
+		e.String("msg", "Get health check history")
+		e.String("remote", r.RemoteAddr)
+	})
+
+	w.Header().Set("Content-Type", contenttypeJSON)
+	json.NewEncoder(w).Encode(h.healthhistory.list())
+}
+
+// authorized checks bearer token of the request for debug endpoints changing state of the host
+func (h *host) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if h.config.DebugToken == "" {
+		h.handlerError(w, r, http.StatusForbidden, "Debug token is not configured")
+		return false
+	}
+
+	// bare token or token of other scheme is not accepted
+	authorization := r.Header.Get("Authorization")
+	token := strings.TrimPrefix(authorization, "Bearer ")
+	if token == authorization || subtle.ConstantTimeCompare([]byte(token), []byte(h.config.DebugToken)) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		h.handlerError(w, r, http.StatusUnauthorized, "Unauthorized")
+		return false
+	}
+
+	return true
+}
+
+func (h *host) healthDrain(w http.ResponseWriter, r *http.Request) {
+	if !h.enterHandler(w, r) {
+		return
+	}
+	defer h.wg.Done()
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost, http.MethodDelete:
+		if !h.authorized(w, r) {
+			return
+		}
+		h.log.Event(mlog.Verbose, func(e mlog.Event) {
+			e.String("msg", "Change drain mode")
+			e.String("remote", r.RemoteAddr)
+			e.String("method", r.Method)
+		})
+		h.SetDraining(r.Method == http.MethodPost)
+	default:
+		h.handlerError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	data := struct {
+		Draining bool `json:"draining"`
+	}{
+		Draining: h.isDraining(),
+	}
+
+	w.Header().Set("Content-Type", contenttypeJSON)
+	json.NewEncoder(w).Encode(data)
+}
+
 func (h *host) buildMuxer() *http.ServeMux {
 	mux := http.NewServeMux()
 
 	// main handlers
 	mux.HandleFunc(pathHealthCheck, h.healthCheck)
+	mux.HandleFunc(pathHealthGrpc, h.grpcHealthCheck)
+	mux.HandleFunc(pathHealthGrpcWatch, h.grpcHealthWatch)
 	mux.HandleFunc(pathMetricsValues, h.metricsValues)
+	mux.HandleFunc(pathMetricsCatalog, h.metricsCatalog)
+	mux.HandleFunc(pathMetricsStream, h.metricsStream)
+	mux.HandleFunc(pathMetricsHistory, h.metricsHistory)
 
 	// enable debug interface
 	if h.config.EnableDebug {
@@ -320,15 +505,23 @@ func (h *host) buildMuxer() *http.ServeMux {
 		mux.HandleFunc(pathDebugPprofSymbol, pprof.Symbol)
 		mux.HandleFunc(pathDebugPprofTrace, pprof.Trace)
 
+		mux.Handle(pathDebugVars, expvar.Handler())
+
 		mux.HandleFunc(pathDebugLoggerLevels, h.loggerLevels)
+		mux.HandleFunc(pathDebugHealthHistory, h.healthHistory)
+		mux.HandleFunc(pathDebugHealthDrain, h.healthDrain)
 
 		// enable collecting data for block and mutex
 		runtime.SetMutexProfileFraction(1)
 		runtime.SetBlockProfileRate(1)
 	}
 
-	// catch all other requests
+	// dashboard at the root path, catch all other requests
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			h.dashboard(w, r)
+			return
+		}
 		h.handlerError(w, r, http.StatusNotFound, "Not Found")
 	})
 
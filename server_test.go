@@ -2,9 +2,14 @@ package metricer
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
+	"io/ioutil"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
+	"strings"
 	"testing"
 
 	"go.melnyk.org/mlog/testlog"
@@ -254,3 +259,295 @@ func TestServerLoggerLevels(t *testing.T) {
 		}
 	}
 }
+
+func TestServerMetricsValuesHealth(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewHealthCheck("health", "health help", func() error {
+		return errors.New("failed")
+	})
+	mhost.registerHistogram("histogram", "histogram help", []float64{1})
+
+	req := httptest.NewRequest("GET", "http://test/health/check", nil)
+	mhost.healthCheck(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest("GET", "http://test/metrics/values", nil)
+	req.Header.Set("Accept", acceptText)
+	w := httptest.NewRecorder()
+	mhost.metricsValues(w, req)
+
+	body, _ := ioutil.ReadAll(w.Result().Body)
+
+	expected := []string{
+		"# TYPE histogram histogram\n",
+		`histogram_bucket{_os="` + runtime.GOOS + `",le="+Inf"} 0`,
+		`_healthcheck_status{_os="` + runtime.GOOS + `",check="health"} 0`,
+		`_healthcheck_duration_seconds_count{_os="` + runtime.GOOS + `",check="health"} 1`,
+		`_healthcheck_transitions{_os="` + runtime.GOOS + `",check="health",from="unknown",to="failed"} 1`,
+	}
+	for i, v := range expected {
+		if !strings.Contains(string(body), v) {
+			t.Errorf("Expected (%d): %s in response, but got %s", i, v, body)
+		}
+	}
+}
+
+func TestServerHealthHistory(t *testing.T) {
+	cfg := &Config{EnableDebug: true}
+	mhost := NewHost(cfg, testlog.NewLogbook()).(*host)
+	mhost.NewHealthCheck("health", "health help", func() error {
+		return nil
+	})
+	muxer := mhost.buildMuxer()
+
+	req := httptest.NewRequest("GET", "http://test/health/check", nil)
+	mhost.healthCheck(httptest.NewRecorder(), req)
+
+	tests := []struct {
+		method string
+		url    string
+		code   int
+	}{
+		{"GET", "http://test/debug/health/history", http.StatusOK},
+		{"POST", "http://test/debug/health/history", http.StatusMethodNotAllowed},
+	}
+
+	for i, v := range tests {
+		req := httptest.NewRequest(v.method, v.url, nil)
+		h, _ := muxer.Handler(req)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		resp := w.Result()
+
+		if resp.StatusCode != v.code {
+			t.Errorf("Expected (%d): %d, but got %d", i, v.code, resp.StatusCode)
+		}
+	}
+
+	req = httptest.NewRequest("GET", "http://test/debug/health/history", nil)
+	w := httptest.NewRecorder()
+	mhost.healthHistory(w, req)
+
+	var history []healthTransition
+	if err := json.NewDecoder(w.Result().Body).Decode(&history); err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+	if len(history) != 1 || history[0].To != "ok" {
+		t.Errorf("Expected: one transition to ok, but got %v", history)
+	}
+}
+
+func TestServerHealthDrain(t *testing.T) {
+	cfg := &Config{EnableDebug: true, DebugToken: "secret"}
+	mhost := NewHost(cfg, testlog.NewLogbook()).(*host)
+	mhost.NewHealthCheck("health", "health help", func() error {
+		return nil
+	})
+	muxer := mhost.buildMuxer()
+
+	tests := []struct {
+		method string
+		url    string
+		token  string
+		code   int
+	}{
+		{"GET", "http://test/health/check", "", http.StatusOK},
+		{"POST", "http://test/debug/health/drain", "", http.StatusUnauthorized},
+		{"POST", "http://test/debug/health/drain", "wrong", http.StatusUnauthorized},
+		{"GET", "http://test/health/check", "", http.StatusOK},
+		{"POST", "http://test/debug/health/drain", "secret", http.StatusOK},
+		{"GET", "http://test/debug/health/drain", "", http.StatusOK},
+		{"GET", "http://test/health/check", "", http.StatusServiceUnavailable},
+		{"DELETE", "http://test/debug/health/drain", "secret", http.StatusOK},
+		{"GET", "http://test/health/check", "", http.StatusOK},
+		{"PUT", "http://test/debug/health/drain", "secret", http.StatusMethodNotAllowed},
+	}
+
+	for i, v := range tests {
+		req := httptest.NewRequest(v.method, v.url, nil)
+		if v.token != "" {
+			req.Header.Set("Authorization", "Bearer "+v.token)
+		}
+		h, _ := muxer.Handler(req)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		resp := w.Result()
+
+		if resp.StatusCode != v.code {
+			t.Errorf("Expected (%d): %d, but got %d", i, v.code, resp.StatusCode)
+		}
+	}
+}
+
+func TestServerAuthorizedScheme(t *testing.T) {
+	mhost := NewHost(&Config{EnableDebug: true, DebugToken: "secret"}, testlog.NewLogbook()).(*host)
+
+	tests := []struct {
+		authorization string
+		authorized    bool
+	}{
+		{"Bearer secret", true},
+		{"secret", false},
+		{"Basic secret", false},
+		{"Bearer  secret", false},
+		{"bearer secret", false},
+		{"", false},
+	}
+
+	for i, v := range tests {
+		req := httptest.NewRequest("POST", "http://test/debug/health/drain", nil)
+		if v.authorization != "" {
+			req.Header.Set("Authorization", v.authorization)
+		}
+		w := httptest.NewRecorder()
+
+		if authorized := mhost.authorized(w, req); authorized != v.authorized {
+			t.Errorf("Expected (%d): %t, but got %t", i, v.authorized, authorized)
+		}
+		if !v.authorized && w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected (%d): %d, but got %d", i, http.StatusUnauthorized, w.Code)
+		}
+	}
+}
+
+func TestServerHealthDrainNoToken(t *testing.T) {
+	cfg := &Config{EnableDebug: true}
+	mhost := NewHost(cfg, testlog.NewLogbook()).(*host)
+
+	req := httptest.NewRequest("POST", "http://test/debug/health/drain", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	w := httptest.NewRecorder()
+	mhost.healthDrain(w, req)
+
+	if resp := w.Result(); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected: %d, but got %d", http.StatusForbidden, resp.StatusCode)
+	}
+	if mhost.isDraining() {
+		t.Error("Expected: drain mode is not enabled")
+	}
+}
+
+func TestServerHealthCheckGroup(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewHealthCheck("db", "db help", func() error {
+		return errors.New("failed")
+	}, HealthGroup("storage"))
+	mhost.NewHealthCheck("schema", "schema help", func() error {
+		return nil
+	}, HealthGroup("storage"), DependsOn("db"))
+	mhost.NewHealthCheck("api", "api help", func() error {
+		return nil
+	}, HealthGroup("external"))
+
+	tests := []struct {
+		url    string
+		code   int
+		checks map[string]string
+	}{
+		{"http://test/health/check", http.StatusServiceUnavailable, map[string]string{"db": "failed", "schema": "skipped", "api": "ok"}},
+		{"http://test/health/check?group=storage", http.StatusServiceUnavailable, map[string]string{"db": "failed", "schema": "skipped"}},
+		{"http://test/health/check?group=external", http.StatusOK, map[string]string{"api": "ok"}},
+		{"http://test/health/check?group=unknown", http.StatusNotFound, nil},
+	}
+
+	for i, v := range tests {
+		req := httptest.NewRequest(http.MethodGet, v.url, nil)
+		w := httptest.NewRecorder()
+		mhost.healthCheck(w, req)
+
+		resp := w.Result()
+
+		if resp.StatusCode != v.code {
+			t.Errorf("Expected (%d): %d, but got %d", i, v.code, resp.StatusCode)
+		}
+		if resp.Header.Get("Content-Type") != contenttypeJSON {
+			t.Errorf("Expected (%d): Content-type JSON, but got %s", i, resp.Header.Get("Content-Type"))
+		}
+
+		data := struct {
+			Checks map[string]string `json:"checks"`
+		}{}
+		json.NewDecoder(resp.Body).Decode(&data)
+		if len(data.Checks) != len(v.checks) {
+			t.Errorf("Expected (%d): %v, but got %v", i, v.checks, data.Checks)
+		}
+		for name, status := range v.checks {
+			if data.Checks[name] != status {
+				t.Errorf("Expected (%d): %s for %s, but got %s", i, status, name, data.Checks[name])
+			}
+		}
+	}
+}
+
+func TestServerHealthCheckGroupDependency(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewHealthCheck("db", "db help", func() error {
+		return errors.New("failed")
+	}, HealthGroup("storage"))
+	mhost.NewHealthCheck("schema", "schema help", func() error {
+		return nil
+	}, HealthGroup("migrations"), DependsOn("db"))
+
+	req := httptest.NewRequest(http.MethodGet, "http://test/health/check?group=migrations", nil)
+	w := httptest.NewRecorder()
+	mhost.healthCheck(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected: %d, but got %d", http.StatusServiceUnavailable, resp.StatusCode)
+	}
+
+	data := struct {
+		Status string            `json:"status"`
+		Metric string            `json:"metric"`
+		Msg    string            `json:"message"`
+		Checks map[string]string `json:"checks"`
+	}{}
+	json.NewDecoder(resp.Body).Decode(&data)
+	if data.Status != "failed" || data.Metric != "schema" || !strings.Contains(data.Msg, "db") {
+		t.Errorf("Expected: failed status with dependency db, but got %v", data)
+	}
+	if len(data.Checks) != 1 || data.Checks["schema"] != "skipped" {
+		t.Errorf("Expected: skipped schema check, but got %v", data.Checks)
+	}
+}
+
+func TestServerMetricsValuesJsonNonFinite(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.registerHistogram("latency", "latency help", []float64{1}).Observe(math.Inf(1))
+	mhost.registerHistogram("size", "size help", []float64{1}).Observe(math.Inf(-1))
+
+	req := httptest.NewRequest("GET", "http://test/metrics/values", nil)
+	req.Header.Set("Accept", acceptJSON)
+	w := httptest.NewRecorder()
+	mhost.metricsValues(w, req)
+
+	var data struct {
+		Metrics struct {
+			Latency map[string]interface{} `json:"latency"`
+			Size    map[string]interface{} `json:"size"`
+		} `json:"metrics"`
+	}
+	if err := json.NewDecoder(w.Result().Body).Decode(&data); err != nil {
+		t.Fatalf("Expected: valid JSON, but got %s", err.Error())
+	}
+	if sum := data.Metrics.Latency["sum"]; sum != "+Inf" {
+		t.Errorf("Expected: +Inf, but got %v", sum)
+	}
+	if sum := data.Metrics.Size["sum"]; sum != "-Inf" {
+		t.Errorf("Expected: -Inf, but got %v", sum)
+	}
+}
+
+func TestServerStoppedHandler(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.Stop()
+
+	req := httptest.NewRequest(http.MethodGet, "http://test/health/check", nil)
+	w := httptest.NewRecorder()
+	mhost.healthCheck(w, req)
+	if code := w.Result().StatusCode; code != http.StatusServiceUnavailable {
+		t.Errorf("Expected: %d, but got %d", http.StatusServiceUnavailable, code)
+	}
+}
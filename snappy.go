@@ -0,0 +1,97 @@
+package metricer
+
+import "encoding/binary"
+
+// snappyEncode encodes data in snappy block format, the data is split into blocks of 64KB
+// and repeated sequences within block are replaced by copies of previous bytes
+func snappyEncode(src []byte) []byte {
+	dst := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(src)+5*(len(src)/snappyMaxLiteral+1))
+	dst = dst[:binary.PutUvarint(dst, uint64(len(src)))]
+
+	for len(src) > 0 {
+		block := src
+		if len(block) > snappyMaxBlock {
+			block = block[:snappyMaxBlock]
+		}
+		src = src[len(block):]
+		dst = snappyEncodeBlock(dst, block)
+	}
+
+	return dst
+}
+
+// snappyEncodeBlock finds matches of 4 bytes sequences using hash table of their last positions
+func snappyEncodeBlock(dst []byte, src []byte) []byte {
+	var table [1 << snappyTableBits]int32 // position of sequence + 1, zero means empty
+
+	start := 0 // start of pending literal
+	for i := 0; i+snappyMinMatch <= len(src); {
+		sequence := binary.LittleEndian.Uint32(src[i:])
+		h := (sequence * 0x1e35a7bd) >> (32 - snappyTableBits)
+		candidate := int(table[h]) - 1
+		table[h] = int32(i + 1)
+
+		if candidate < 0 || binary.LittleEndian.Uint32(src[candidate:]) != sequence {
+			i++
+			continue
+		}
+
+		length := snappyMinMatch
+		for i+length < len(src) && src[candidate+length] == src[i+length] {
+			length++
+		}
+
+		dst = snappyLiteral(dst, src[start:i])
+		dst = snappyCopy(dst, i-candidate, length)
+		i += length
+		start = i
+	}
+
+	return snappyLiteral(dst, src[start:])
+}
+
+// snappyLiteral appends literal elements of the data
+func snappyLiteral(dst []byte, literal []byte) []byte {
+	for len(literal) > 0 {
+		chunk := literal
+		if len(chunk) > snappyMaxLiteral {
+			chunk = chunk[:snappyMaxLiteral]
+		}
+		literal = literal[len(chunk):]
+
+		n := len(chunk) - 1
+		switch {
+		case n < 60:
+			dst = append(dst, byte(n)<<2)
+		case n < 1<<8:
+			dst = append(dst, 60<<2, byte(n))
+		default:
+			dst = append(dst, 61<<2, byte(n), byte(n>>8))
+		}
+		dst = append(dst, chunk...)
+	}
+	return dst
+}
+
+// snappyCopy appends copy elements, copy with 2 bytes offset holds up to 64 bytes,
+// short copies with small offset use 1 byte offset
+func snappyCopy(dst []byte, offset int, length int) []byte {
+	copy2 := func(dst []byte, length int) []byte {
+		return append(dst, byte(length-1)<<2|2, byte(offset), byte(offset>>8))
+	}
+
+	// the last copy keeps at least 4 bytes
+	for length >= 68 {
+		dst = copy2(dst, 64)
+		length -= 64
+	}
+	if length > 64 {
+		dst = copy2(dst, 60)
+		length -= 60
+	}
+
+	if length >= 12 || offset >= 2048 {
+		return copy2(dst, length)
+	}
+	return append(dst, byte(offset>>8)<<5|byte(length-4)<<2|1, byte(offset))
+}
@@ -0,0 +1,119 @@
+package metricer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"testing"
+)
+
+// snappyDecode decodes snappy block
+func snappyDecode(t *testing.T, src []byte) []byte {
+	size, n := binary.Uvarint(src)
+	if n <= 0 {
+		t.Fatalf("Expected: valid length preamble, but got %v", src)
+	}
+	src = src[n:]
+
+	var dst []byte
+	for len(src) > 0 {
+		tag := src[0]
+		src = src[1:]
+
+		var offset, length int
+		switch tag & 3 {
+		case 0:
+			length = int(tag >> 2)
+			switch length {
+			case 60:
+				length = int(src[0])
+				src = src[1:]
+			case 61:
+				length = int(binary.LittleEndian.Uint16(src))
+				src = src[2:]
+			}
+			length++
+			dst = append(dst, src[:length]...)
+			src = src[length:]
+			continue
+		case 1:
+			length = int(tag>>2&7) + 4
+			offset = int(tag>>5)<<8 | int(src[0])
+			src = src[1:]
+		case 2:
+			length = int(tag>>2) + 1
+			offset = int(binary.LittleEndian.Uint16(src))
+			src = src[2:]
+		default:
+			t.Fatalf("Expected: known tag, but got %x", tag)
+		}
+
+		if offset == 0 || offset > len(dst) {
+			t.Fatalf("Expected: valid offset, but got %d for %d bytes", offset, len(dst))
+		}
+		// copies may overlap with the bytes being written
+		for i := 0; i < length; i++ {
+			dst = append(dst, dst[len(dst)-offset])
+		}
+	}
+
+	if uint64(len(dst)) != size {
+		t.Fatalf("Expected: %d bytes, but got %d", size, len(dst))
+	}
+	return dst
+}
+
+func TestSnappyEncode(t *testing.T) {
+	if result := snappyEncode([]byte("hello")); !bytes.Equal(result, []byte{0x05, 0x10, 'h', 'e', 'l', 'l', 'o'}) {
+		t.Errorf("Expected: hello literal, but got %v", result)
+	}
+	if result := snappyEncode(nil); !bytes.Equal(result, []byte{0}) {
+		t.Errorf("Expected: empty block, but got %v", result)
+	}
+
+	for _, size := range []int{59, 60, 61, 256, 257, snappyMaxLiteral, snappyMaxLiteral + 1, 3*snappyMaxLiteral + 100} {
+		src := make([]byte, size)
+		for i := range src {
+			src[i] = byte(i)
+		}
+		if result := snappyDecode(t, snappyEncode(src)); !bytes.Equal(result, src) {
+			t.Errorf("Expected: the same data for %d bytes", size)
+		}
+	}
+}
+
+func TestSnappyEncodeCompression(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	noise := make([]byte, 1000)
+	random.Read(noise)
+
+	tests := []struct {
+		name  string
+		src   []byte
+		ratio float64 // max size of encoded data relative to source
+	}{
+		{"repeated byte", bytes.Repeat([]byte{'a'}, 100000), 0.05},
+		{"repeated series", bytes.Repeat([]byte(`http_requests_total{method="get",code="200"} 42`+"\n"), 2000), 0.05},
+		{"short distance", bytes.Repeat([]byte("abcdefgh"), 50), 0.2},
+		{"random with repeats", append(append(append([]byte{}, noise...), noise[100:900]...), noise...), 0.6},
+	}
+
+	for _, v := range tests {
+		encoded := snappyEncode(v.src)
+		if result := snappyDecode(t, encoded); !bytes.Equal(result, v.src) {
+			t.Errorf("Expected: the same data for %s", v.name)
+		}
+		if ratio := float64(len(encoded)) / float64(len(v.src)); ratio > v.ratio {
+			t.Errorf("Expected: %s compressed to %f, but got %f", v.name, v.ratio, ratio)
+		}
+	}
+
+	// random data without repeats stays literal
+	for _, size := range []int{1, 3, 4, 5, 1000, snappyMaxBlock + 7} {
+		src := make([]byte, size)
+		random.Read(src)
+		if result := snappyDecode(t, snappyEncode(src)); !bytes.Equal(result, src) {
+			t.Errorf("Expected: the same data for %d random bytes", size)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package metricer
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"time"
+)
+
+// StatsdConfig represents configuration of StatsD exporter
+type StatsdConfig struct {
+	// Address of StatsD daemon, host:port
+	Address string `json:"address,omitempty" yaml:"address"`
+
+	// Prefix added to every metric name
+	Prefix string `json:"prefix,omitempty" yaml:"prefix"`
+
+	// Interval between pushes
+	Interval time.Duration `json:"interval,omitempty" yaml:"interval"`
+
+	// MTU is max size of UDP packet
+	MTU int `json:"mtu,omitempty" yaml:"mtu"`
+
+	// Tags enables DogStatsD tags built from labels
+	Tags bool `json:"tags,omitempty" yaml:"tags"`
+}
+
+// Validate checks StatsD config structure
+func (config *StatsdConfig) Validate() error {
+	if config.Address == "" {
+		config.Address = defaultStatsdAddress
+	}
+	if config.Interval <= 0 {
+		config.Interval = defaultPushInterval
+	}
+	if config.MTU <= 0 {
+		config.MTU = defaultStatsdMTU
+	}
+	return nil
+}
+
+type statsd struct {
+	config StatsdConfig
+	conn   net.Conn
+	last   map[string]float64 // last values of counters to calculate deltas
+}
+
+func newStatsd(config StatsdConfig) *statsd {
+	return &statsd{
+		config: config,
+		last:   make(map[string]float64),
+	}
+}
+
+func (exporter *statsd) name() string {
+	return "statsd"
+}
+
+func (exporter *statsd) interval() time.Duration {
+	return exporter.config.Interval
+}
+
+func (exporter *statsd) close() error {
+	if exporter.conn == nil {
+		return nil
+	}
+	return exporter.conn.Close()
+}
+
+func (exporter *statsd) push(snap *snapshot) error {
+	if exporter.conn == nil {
+		conn, err := net.Dial("udp", exporter.config.Address)
+		if err != nil {
+			return err
+		}
+		exporter.conn = conn
+	}
+
+	var packet bytes.Buffer
+	var lasterr error
+
+	write := func(name string, labels []labelPair, value float64, kind string) {
+		line := exporter.line(name, snap.labels, labels, value, kind)
+		// leading sign of gauge means relative change in StatsD, so negative value is set after reset to zero
+		// in the same packet
+		if kind == "g" && value < 0 {
+			line = exporter.line(name, snap.labels, labels, 0, kind) + "\n" + line
+		}
+		if packet.Len() > 0 && packet.Len()+1+len(line) > exporter.config.MTU {
+			if _, err := exporter.conn.Write(packet.Bytes()); err != nil {
+				lasterr = err
+			}
+			packet.Reset()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+
+	for _, f := range snap.families {
+		for _, s := range f.series {
+			switch {
+			case s.hist != nil:
+				write(f.name+".count", s.labels, exporter.delta(f.name+".count", s.labels, float64(s.hist.count)), "c")
+				write(f.name+".sum", s.labels, exporter.delta(f.name+".sum", s.labels, s.hist.sum), "c")
+			case f.kind == kindCounter:
+				write(f.name, s.labels, exporter.delta(f.name, s.labels, s.value), "c")
+			default:
+				write(f.name, s.labels, s.value, "g")
+			}
+		}
+	}
+
+	if packet.Len() > 0 {
+		if _, err := exporter.conn.Write(packet.Bytes()); err != nil {
+			lasterr = err
+		}
+	}
+
+	return lasterr
+}
+
+// delta returns change of the counter since the last push
+func (exporter *statsd) delta(name string, labels []labelPair, value float64) float64 {
+	key := name + formatLabels(labels)
+	delta := value - exporter.last[key]
+	exporter.last[key] = value
+	return delta
+}
+
+var statsdEscaper = strings.NewReplacer(":", "_", "|", "_", "@", "_", "#", "_", ",", "_", " ", "_", "\n", "_")
+
+// line formats metric in StatsD format, labels are added as DogStatsD tags or as part of the name
+func (exporter *statsd) line(name string, hostlabels []labelPair, labels []labelPair, value float64, kind string) string {
+	var b strings.Builder
+	if exporter.config.Prefix != "" {
+		b.WriteString(statsdEscaper.Replace(exporter.config.Prefix))
+		b.WriteByte('.')
+	}
+	b.WriteString(statsdEscaper.Replace(name))
+
+	if !exporter.config.Tags {
+		for _, v := range labels {
+			b.WriteByte('.')
+			b.WriteString(statsdEscaper.Replace(v.value))
+		}
+	}
+
+	b.WriteByte(':')
+	b.WriteString(formatValue(value))
+	b.WriteByte('|')
+	b.WriteString(kind)
+
+	if exporter.config.Tags && len(hostlabels)+len(labels) > 0 {
+		b.WriteString("|#")
+		first := true
+		for _, list := range [][]labelPair{hostlabels, labels} {
+			for _, v := range list {
+				if !first {
+					b.WriteByte(',')
+				}
+				first = false
+				b.WriteString(statsdEscaper.Replace(v.name))
+				b.WriteByte(':')
+				b.WriteString(statsdEscaper.Replace(v.value))
+			}
+		}
+	}
+
+	return b.String()
+}
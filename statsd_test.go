@@ -0,0 +1,161 @@
+package metricer
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"go.melnyk.org/mlog/testlog"
+)
+
+func listenStatsd(t *testing.T) *net.UDPConn {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Expected: UDP listener, but got %s", err.Error())
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+func readStatsd(t *testing.T, conn *net.UDPConn) []string {
+	buf := make([]byte, 65536)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("Expected: StatsD packet, but got %s", err.Error())
+	}
+	return strings.Split(string(buf[:n]), "\n")
+}
+
+func TestStatsdConfigDefaults(t *testing.T) {
+	cfg := &Config{Statsd: &StatsdConfig{}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+	if cfg.Statsd.Address != defaultStatsdAddress || cfg.Statsd.Interval != defaultPushInterval || cfg.Statsd.MTU != defaultStatsdMTU {
+		t.Errorf("Expected: default StatsD config values, but got %v", cfg.Statsd)
+	}
+}
+
+func TestStatsdLine(t *testing.T) {
+	hostlabels := []labelPair{{name: "_os", value: "linux"}}
+	labels := []labelPair{{name: "check", value: "db:main"}}
+
+	tests := []struct {
+		config   StatsdConfig
+		expected string
+	}{
+		{StatsdConfig{}, "name.db_main:5|c"},
+		{StatsdConfig{Prefix: "app"}, "app.name.db_main:5|c"},
+		{StatsdConfig{Tags: true}, "name:5|c|#_os:linux,check:db_main"},
+	}
+
+	for i, v := range tests {
+		exporter := newStatsd(v.config)
+		if line := exporter.line("name", hostlabels, labels, 5, "c"); line != v.expected {
+			t.Errorf("Expected (%d): %s, but got %s", i, v.expected, line)
+		}
+	}
+}
+
+func TestStatsdPush(t *testing.T) {
+	conn := listenStatsd(t)
+	defer conn.Close()
+
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	counter := mhost.NewCounter("counter", "counter help")
+	gauge := mhost.NewGauge("gauge", "gauge help")
+	histogram := mhost.registerHistogram("histogram", "histogram help", nil)
+
+	exporter := newStatsd(StatsdConfig{Address: conn.LocalAddr().String(), Prefix: "app", MTU: 65000})
+	defer exporter.close()
+
+	counter.Inc(10)
+	gauge.Update(7)
+	histogram.Observe(1.5)
+	if err := exporter.push(mhost.collect()); err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+	lines := strings.Join(readStatsd(t, conn), "\n")
+	for _, v := range []string{"app.counter:10|c", "app.gauge:7|g", "app.histogram.count:1|c", "app.histogram.sum:1.5|c"} {
+		if !strings.Contains(lines, v) {
+			t.Errorf("Expected: %s in packet, but got %s", v, lines)
+		}
+	}
+
+	// counters are sent as deltas
+	counter.Inc(5)
+	if err := exporter.push(mhost.collect()); err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+	lines = strings.Join(readStatsd(t, conn), "\n")
+	if !strings.Contains(lines, "app.counter:5|c") {
+		t.Errorf("Expected: counter delta in packet, but got %s", lines)
+	}
+}
+
+func TestStatsdPushNegativeGauge(t *testing.T) {
+	conn := listenStatsd(t)
+	defer conn.Close()
+
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	mhost.NewGauge("gauge", "gauge help").Update(-3)
+
+	exporter := newStatsd(StatsdConfig{Address: conn.LocalAddr().String(), Prefix: "app", MTU: 65000})
+	defer exporter.close()
+
+	if err := exporter.push(mhost.collect()); err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+	lines := strings.Join(readStatsd(t, conn), "\n")
+	if !strings.Contains(lines, "app.gauge:0|g\napp.gauge:-3|g") {
+		t.Errorf("Expected: gauge reset to zero before negative value, but got %s", lines)
+	}
+}
+
+func TestStatsdPushBatching(t *testing.T) {
+	conn := listenStatsd(t)
+	defer conn.Close()
+
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+	exporter := newStatsd(StatsdConfig{Address: conn.LocalAddr().String(), MTU: 64})
+	defer exporter.close()
+
+	if err := exporter.push(mhost.collect()); err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+
+	packets := 0
+	conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	buf := make([]byte, 65536)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			break
+		}
+		packets++
+		if n > 64 && strings.Contains(string(buf[:n]), "\n") {
+			t.Errorf("Expected: packet within MTU, but got %d bytes", n)
+		}
+	}
+	if packets < 2 {
+		t.Errorf("Expected: several packets, but got %d", packets)
+	}
+}
+
+func TestStatsdHostLifecycle(t *testing.T) {
+	conn := listenStatsd(t)
+	defer conn.Close()
+
+	cfg := &Config{Statsd: &StatsdConfig{Address: conn.LocalAddr().String(), Interval: time.Hour}}
+	mhost := NewHost(cfg, testlog.NewLogbook())
+	mhost.NewCounter("counter", "counter help").Inc(3)
+	mhost.Start()
+	mhost.Stop()
+
+	// the last push is done on stop
+	lines := strings.Join(readStatsd(t, conn), "\n")
+	if !strings.Contains(lines, "counter:3|c") {
+		t.Errorf("Expected: counter in packet, but got %s", lines)
+	}
+}
@@ -0,0 +1,124 @@
+package metricer
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.melnyk.org/mlog"
+)
+
+// filterFamilies returns snapshot containing only families with one of the name prefixes, empty list keeps all families
+func filterFamilies(snap *snapshot, prefixes []string) *snapshot {
+	if len(prefixes) == 0 {
+		return snap
+	}
+
+	filtered := *snap
+	filtered.families = nil
+	for _, f := range snap.families {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(f.name, prefix) {
+				filtered.families = append(filtered.families, f)
+				break
+			}
+		}
+	}
+	return &filtered
+}
+
+// changedMetrics returns metrics whose JSON encoding differs from the previous one, previous values are updated
+func changedMetrics(metrics map[string]interface{}, previous map[string]string) map[string]interface{} {
+	changed := make(map[string]interface{})
+	for name, value := range metrics {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			continue
+		}
+		if v, ok := previous[name]; ok && v == string(encoded) {
+			continue
+		}
+		previous[name] = string(encoded)
+		changed[name] = value
+	}
+	return changed
+}
+
+// metricsStream sends metrics values as Server-Sent Events, the first event contains all metrics,
+// the next ones only changed metrics
+func (h *host) metricsStream(w http.ResponseWriter, r *http.Request) {
+	if !h.enterHandler(w, r) {
+		return
+	}
+	defer h.wg.Done()
+
+	// only GET method is allowed
+	if r.Method != http.MethodGet {
+		h.handlerError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.handlerError(w, r, http.StatusInternalServerError, "Streaming is not supported")
+		return
+	}
+
+	if atomic.AddInt32(&h.streams, 1) > int32(h.config.MaxStreams) {
+		atomic.AddInt32(&h.streams, -1)
+		h.handlerError(w, r, http.StatusServiceUnavailable, "Too many streams")
+		return
+	}
+	defer atomic.AddInt32(&h.streams, -1)
+
+	names := r.URL.Query()["name"]
+
+	h.log.Event(mlog.Verbose, func(e mlog.Event) {
+		e.String("msg", "Metrics stream started")
+		e.String("remote", r.RemoteAddr)
+		e.String("name", strings.Join(names, ","))
+	})
+
+	w.Header().Set("Content-Type", contenttypeEventStream)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // disable buffering by nginx
+	w.WriteHeader(http.StatusOK)
+
+	previous := make(map[string]string)
+	send := func() error {
+		h.updateRuntime()
+		changed := changedMetrics(jsonMetrics(filterFamilies(h.collect(), names)), previous)
+
+		// comment keeps connection alive when nothing has changed
+		if len(changed) == 0 {
+			_, err := w.Write([]byte(": keepalive\n\n"))
+			flusher.Flush()
+			return err
+		}
+
+		data, err := json.Marshal(changed)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write([]byte("event: metrics\ndata: " + string(data) + "\n\n")); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	ticker := time.NewTicker(h.config.StreamInterval)
+	defer ticker.Stop()
+
+	for err := send(); err == nil; err = send() {
+		select {
+		case <-ticker.C:
+		case <-h.done:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
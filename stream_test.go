@@ -0,0 +1,138 @@
+package metricer
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.melnyk.org/mlog/testlog"
+)
+
+// readEvent reads the next event data skipping keepalive comments
+func readEvent(t *testing.T, r *bufio.Reader) map[string]interface{} {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Expected: event, but got %s", err.Error())
+		}
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+
+		data := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(line[len("data: "):]), &data); err != nil {
+			t.Fatalf("Expected: JSON data, but got %s", err.Error())
+		}
+		return data
+	}
+}
+
+func TestMetricsStream(t *testing.T) {
+	mhost := NewHost(&Config{StreamInterval: 10 * time.Millisecond}, testlog.NewLogbook()).(*host)
+	counter := mhost.NewCounter("requests", "requests help")
+	mhost.NewGauge("connections", "connections help")
+
+	server := httptest.NewServer(mhost.buildMuxer())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + pathMetricsStream + "?name=requests&name=conn")
+	if err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get("Content-Type") != contenttypeEventStream {
+		t.Errorf("Expected: Content-type event stream, but got %s", resp.Header.Get("Content-Type"))
+	}
+
+	r := bufio.NewReader(resp.Body)
+
+	// the first event contains all filtered metrics
+	data := readEvent(t, r)
+	if len(data) != 2 || data["requests"] != 0.0 || data["connections"] != 0.0 {
+		t.Errorf("Expected: all filtered metrics, but got %v", data)
+	}
+
+	// the next ones contain only changed metrics
+	counter.Inc(1)
+	data = readEvent(t, r)
+	if len(data) != 1 || data["requests"] != 1.0 {
+		t.Errorf("Expected: changed metric only, but got %v", data)
+	}
+
+	// stream is closed on stop
+	mhost.once.Do(func() {
+		close(mhost.done)
+	})
+	mhost.wg.Wait()
+
+	if streams := atomic.LoadInt32(&mhost.streams); streams != 0 {
+		t.Errorf("Expected: no active streams, but got %d", streams)
+	}
+}
+
+func TestMetricsStreamLimit(t *testing.T) {
+	mhost := NewHost(&Config{MaxStreams: 1}, testlog.NewLogbook()).(*host)
+
+	server := httptest.NewServer(mhost.buildMuxer())
+	defer server.Close()
+
+	first, err := http.Get(server.URL + pathMetricsStream)
+	if err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+	defer first.Body.Close()
+	readEvent(t, bufio.NewReader(first.Body))
+
+	second, err := http.Get(server.URL + pathMetricsStream)
+	if err != nil {
+		t.Fatalf("Expected: no errors, but got %s", err.Error())
+	}
+	second.Body.Close()
+
+	if second.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected: %d, but got %d", http.StatusServiceUnavailable, second.StatusCode)
+	}
+
+	mhost.once.Do(func() {
+		close(mhost.done)
+	})
+}
+
+func TestMetricsStreamMethod(t *testing.T) {
+	mhost := NewHost(nil, testlog.NewLogbook()).(*host)
+
+	req := httptest.NewRequest("POST", "http://test"+pathMetricsStream, nil)
+	w := httptest.NewRecorder()
+	mhost.metricsStream(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected: %d, but got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
+
+func TestChangedMetrics(t *testing.T) {
+	previous := make(map[string]string)
+
+	tests := []struct {
+		metrics map[string]interface{}
+		changed int
+	}{
+		{map[string]interface{}{"a": 1.0, "b": 2.0}, 2},
+		{map[string]interface{}{"a": 1.0, "b": 3.0}, 1},
+		{map[string]interface{}{"a": 1.0, "b": 3.0}, 0},
+		{map[string]interface{}{"h": map[string]interface{}{"count": 1}}, 1},
+		{map[string]interface{}{"h": map[string]interface{}{"count": 1}}, 0},
+	}
+
+	for i, v := range tests {
+		if changed := changedMetrics(v.metrics, previous); len(changed) != v.changed {
+			t.Errorf("Expected (%d): %d changed metrics, but got %v", i, v.changed, changed)
+		}
+	}
+}